@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+
+	"github.com/mevansam/goutils/logger"
+)
+
+// The passphrase agent holds the passphrase that unlocks the
+// CLI configuration in memory so that it does not need to be
+// entered on every invocation of the CLI or be exported to
+// the environment. The agent listens on a unix socket which
+// can only be accessed by the user that started the agent.
+// The passphrase is discarded once the unlock timeout expires.
+
+const (
+	opGet  = "get"
+	opSet  = "set"
+	opLock = "lock"
+	opStop = "stop"
+	opPing = "ping"
+)
+
+type request struct {
+	Op         string `json:"op"`
+	Passphrase string `json:"passphrase,omitempty"`
+	Timeout    int64  `json:"timeout,omitempty"`
+}
+
+type response struct {
+	Passphrase string `json:"passphrase,omitempty"`
+	Unlocked   bool   `json:"unlocked"`
+	ExpiresAt  int64  `json:"expiresAt,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// returns the path of the agent's socket which can be
+// overridden via the CBS_AGENT_SOCK environment variable
+func SocketPath() string {
+
+	if path := os.Getenv("CBS_AGENT_SOCK"); len(path) > 0 {
+		return path
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		home = os.TempDir()
+	}
+	return filepath.Join(home, ".cb", "agent.sock")
+}
+
+type Agent struct {
+	socketPath string
+	listener   net.Listener
+
+	mx         sync.Mutex
+	passphrase string
+	expiresAt  time.Time
+	expiry     *time.Timer
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewAgent(socketPath string) *Agent {
+	return &Agent{
+		socketPath: socketPath,
+		done:       make(chan struct{}),
+	}
+}
+
+// starts listening on the agent's socket. the socket
+// is created with permissions that restrict access
+// to the user running the agent.
+func (a *Agent) Listen() error {
+
+	var (
+		err error
+	)
+
+	if err = os.MkdirAll(filepath.Dir(a.socketPath), 0700); err != nil {
+		return err
+	}
+	if IsRunning() {
+		return fmt.Errorf("an agent is already listening on '%s'", a.socketPath)
+	}
+	// remove stale socket left behind by an agent
+	// that did not shutdown cleanly
+	_ = os.Remove(a.socketPath)
+
+	oldMask := umask(0077)
+	a.listener, err = net.Listen("unix", a.socketPath)
+	umask(oldMask)
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(a.socketPath, 0600); err != nil {
+		a.listener.Close()
+		return err
+	}
+	return nil
+}
+
+// serves requests until the agent is stopped
+func (a *Agent) Serve() error {
+
+	var (
+		err error
+
+		conn net.Conn
+	)
+
+	defer os.Remove(a.socketPath)
+
+	for {
+		if conn, err = a.listener.Accept(); err != nil {
+			select {
+			case <-a.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go a.handle(conn)
+	}
+}
+
+// unlocks the agent with the given passphrase
+// which will be discarded after the timeout
+func (a *Agent) Unlock(passphrase string, timeout time.Duration) {
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	if timeout <= 0 {
+		// an unlock timeout of 0 means the
+		// passphrase should never be retained
+		a.passphrase = ""
+		return
+	}
+	a.passphrase = passphrase
+	a.expiresAt = time.Now().Add(timeout)
+	if a.expiry != nil {
+		a.expiry.Stop()
+	}
+	a.expiry = time.AfterFunc(timeout, a.Lock)
+}
+
+// discards the passphrase held by the agent
+func (a *Agent) Lock() {
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	a.passphrase = ""
+	a.expiresAt = time.Time{}
+	if a.expiry != nil {
+		a.expiry.Stop()
+		a.expiry = nil
+	}
+}
+
+// stops the agent. it is safe to call this more
+// than once such as when stop requests race.
+func (a *Agent) Stop() {
+	a.Lock()
+	a.stopOnce.Do(func() {
+		close(a.done)
+		a.listener.Close()
+	})
+}
+
+func (a *Agent) handle(conn net.Conn) {
+
+	var (
+		err error
+
+		req  request
+		resp response
+	)
+
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err = json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		logger.ErrorMessage("Agent.handle(): Unable to read request: %s", err.Error())
+		return
+	}
+
+	stop := false
+	switch req.Op {
+	case opGet, opPing:
+	case opSet:
+		a.Unlock(req.Passphrase, time.Duration(req.Timeout)*time.Second)
+	case opLock:
+		a.Lock()
+	case opStop:
+		stop = true
+	default:
+		resp.Error = fmt.Sprintf("unknown operation '%s'", req.Op)
+	}
+
+	a.mx.Lock()
+	resp.Unlocked = len(a.passphrase) > 0
+	if resp.Unlocked {
+		resp.ExpiresAt = a.expiresAt.Unix()
+		if req.Op == opGet {
+			resp.Passphrase = a.passphrase
+		}
+	}
+	a.mx.Unlock()
+
+	if err = json.NewEncoder(conn).Encode(&resp); err != nil {
+		logger.ErrorMessage("Agent.handle(): Unable to write response: %s", err.Error())
+	}
+	if stop {
+		a.Stop()
+	}
+}
+
+// agent client functions
+
+func send(req *request) (*response, error) {
+
+	var (
+		err error
+
+		conn net.Conn
+	)
+
+	if conn, err = net.DialTimeout("unix", SocketPath(), time.Second); err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	resp := &response{}
+	if err = json.NewDecoder(bufio.NewReader(conn)).Decode(resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
+
+// returns true if an agent is listening
+// on the agent socket
+func IsRunning() bool {
+	_, err := send(&request{Op: opPing})
+	return err == nil
+}
+
+// returns the passphrase held by the agent. the returned
+// flag is false if the agent is not running or locked.
+func GetPassphrase() (string, bool) {
+
+	resp, err := send(&request{Op: opGet})
+	if err != nil {
+		logger.TraceMessage("Unable to retrieve passphrase from agent: %s", err.Error())
+		return "", false
+	}
+	return resp.Passphrase, resp.Unlocked
+}
+
+// unlocks the agent with the given passphrase
+func SetPassphrase(passphrase string, timeout time.Duration) error {
+	_, err := send(&request{
+		Op:         opSet,
+		Passphrase: passphrase,
+		Timeout:    int64(timeout / time.Second),
+	})
+	return err
+}
+
+// returns whether the agent is unlocked and
+// the time at which the passphrase expires
+func Status() (bool, time.Time, error) {
+
+	resp, err := send(&request{Op: opPing})
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if !resp.Unlocked {
+		return false, time.Time{}, nil
+	}
+	return true, time.Unix(resp.ExpiresAt, 0), nil
+}
+
+func Lock() error {
+	_, err := send(&request{Op: opLock})
+	return err
+}
+
+func Stop() error {
+	_, err := send(&request{Op: opStop})
+	return err
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package agent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func umask(mask int) int {
+	return syscall.Umask(mask)
+}
+
+// detaches the agent process from the
+// terminal session it was started in
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package agent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// the socket file on windows inherits the
+// access control list of the user's home
+func umask(mask int) int {
+	return 0
+}
+
+// detaches the agent process from the
+// console it was started in
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: 0x00000008 /* DETACHED_PROCESS */ | syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
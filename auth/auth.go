@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
@@ -31,6 +32,11 @@ import (
 
 var callbackPorts = []int{9080, 19080, 29080, 39080, 49080, 59080}
 
+// set via the global '--device-code' option to login using
+// the OAuth 2.0 device authorization grant instead of a
+// browser redirect to a localhost callback
+var DeviceCodeLogin bool
+
 func Authenticate(config config.Config, loginMessages ...string) error {
 
 	var (
@@ -38,20 +44,23 @@ func Authenticate(config config.Config, loginMessages ...string) error {
 
 		isAuthenticated bool
 		authUrl string
+
+		s *spinner.Spinner
 	)
 
+	oauthConfig := &oauth2.Config{
+		ClientID:     cbcli_config.CLIENT_ID,
+		ClientSecret: cbcli_config.CLIENT_SECRET,
+		Scopes:       []string{"openid", "profile"},
+		
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  cbcli_config.AUTH_URL,
+			TokenURL: cbcli_config.OIDCTokenURL,
+		},
+	}
 	authn := auth.NewAuthenticator(
 		config.AuthContext(),
-		&oauth2.Config{
-			ClientID:     cbcli_config.CLIENT_ID,
-			ClientSecret: cbcli_config.CLIENT_SECRET,
-			Scopes:       []string{"openid", "profile"},
-			
-			Endpoint: oauth2.Endpoint{
-				AuthURL:  cbcli_config.AUTH_URL,
-				TokenURL: cbcli_config.TOKEN_URL,
-			},
-		}, 
+		oauthConfig, 
 		callBackHandler,
 	)
 
@@ -63,43 +72,44 @@ func Authenticate(config config.Config, loginMessages ...string) error {
 			fmt.Println()
 			cbcli_utils.ShowNoticeMessage(loginMessages[0])
 		}
-		if authUrl, err = authn.StartOAuthFlow(callbackPorts, logoRequestHandler); err != nil {
-			logger.DebugMessage("ERROR! Authentication failed: %s", err.Error())	
-			return err
-		}
-		if err = openBrowser(authUrl); err != nil {
-			logger.DebugMessage("ERROR! Unable to open browser for authentication: %s", err.Error())
+		if DeviceCodeLogin {
+			if s, err = authenticateWithDeviceCode(config, oauthConfig); err != nil {
+				logger.DebugMessage("ERROR! Device code authentication failed: %s", err.Error())	
+				return err
+			}
+		} else {
+			if authUrl, err = authn.StartOAuthFlow(callbackPorts, logoRequestHandler); err != nil {
+				logger.DebugMessage("ERROR! Authentication failed: %s", err.Error())	
+				return err
+			}
+			if err = openBrowser(authUrl); err != nil {
+				logger.DebugMessage("ERROR! Unable to open browser for authentication: %s", err.Error())
 
-			fmt.Println()
-			cbcli_utils.ShowNoteMessage(
-				"You need to open a browser window and navigate to the following URL in order to " +
-				"login to your My Cloud Space account. Once authenticated the CLI will be ready " +
-				"for use.",
-			)
-			fmt.Printf("\n=> %s\n\n", authUrl)
+				fmt.Println()
+				cbcli_utils.ShowNoteMessage(
+					"You need to open a browser window and navigate to the following URL in order to " +
+					"login to your My Cloud Space account. Once authenticated the CLI will be ready " +
+					"for use.",
+				)
+				fmt.Printf("\n=> %s\n\n", authUrl)
 
-		} else {
-			fmt.Println()
-			cbcli_utils.ShowNoteMessage(
-				"You have been directed to a browser window from which you need to login to your " +
-				"My Cloud Space account. Once authenticated the CLI will be ready for use.",
-			)
-			fmt.Println()
-		}
-		
-		s := spinner.New(
-			spinner.CharSets[cbcli_config.SpinnerNetworkType], 
-			100*time.Millisecond,
-			spinner.WithSuffix(" Waiting for authentication to complete."),
-			spinner.WithFinalMSG(color.Green.Render("Authentication is complete. You are now signed in.\n")),
-			spinner.WithHiddenCursor(true),
-		)
-		s.Start()
-		for wait := true; wait; {
-			wait, err = authn.WaitForOAuthFlowCompletion(time.Second)
-		}
-		if err != nil {
-			return err
+			} else {
+				fmt.Println()
+				cbcli_utils.ShowNoteMessage(
+					"You have been directed to a browser window from which you need to login to your " +
+					"My Cloud Space account. Once authenticated the CLI will be ready for use.",
+				)
+				fmt.Println()
+			}
+			
+			s = newAuthSpinner()
+			s.Start()
+			for wait := true; wait; {
+				wait, err = authn.WaitForOAuthFlowCompletion(time.Second)
+			}
+			if err != nil {
+				return err
+			}
 		}
 		// update app config with cloud properties
 		cloudAPI := mycscloud.NewCloudAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
@@ -112,6 +122,57 @@ func Authenticate(config config.Config, loginMessages ...string) error {
 	return nil
 }
 
+// authenticates using the OAuth 2.0 device authorization grant. the
+// user is shown a verification URL and code which they can open on
+// any device with a browser. the token endpoint is polled until the
+// user completes the authorization and the token is then saved to
+// the config's auth context exactly as the browser flow does.
+func authenticateWithDeviceCode(config config.Config, oauthConfig *oauth2.Config) (*spinner.Spinner, error) {
+
+	var (
+		err error
+
+		da    *deviceAuthorization
+		token *oauth2.Token
+	)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if da, err = requestDeviceAuthorization(client, cbcli_config.OIDCDeviceAuthURL, oauthConfig); err != nil {
+		return nil, err
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage(
+		"To login to your My Cloud Space account open the following URL in a browser on any " +
+		"device and enter the code shown below. Once authenticated the CLI will be ready for use.",
+	)
+	fmt.Printf("\n=> %s\n", da.VerificationURI)
+	fmt.Printf("\n   Code: %s\n\n", color.OpBold.Render(da.UserCode))
+	if len(da.VerificationURIComplete) > 0 {
+		fmt.Printf("You can also open the following URL which includes the code.\n\n=> %s\n\n", da.VerificationURIComplete)
+	}
+
+	s := newAuthSpinner()
+	s.Start()
+	if token, err = pollDeviceAccessToken(context.Background(), client, oauthConfig, da); err != nil {
+		s.FinalMSG = ""
+		s.Stop()
+		return nil, err
+	}
+	config.AuthContext().SetToken(token)
+	return s, nil
+}
+
+func newAuthSpinner() *spinner.Spinner {
+	return spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType], 
+		100*time.Millisecond,
+		spinner.WithSuffix(" Waiting for authentication to complete."),
+		spinner.WithFinalMSG(color.Green.Render("Authentication is complete. You are now signed in.\n")),
+		spinner.WithHiddenCursor(true),
+	)
+}
+
 func callBackHandler(w http.ResponseWriter, r *http.Request) {
 	if _, err := w.Write([]byte(authSuccessHTML)); err != nil {
 		logger.DebugMessage("ERROR! Unable to return auth success open page.")
@@ -220,7 +281,7 @@ func AuthorizeDeviceAndUser(config config.Config) error {
 			cbcli_utils.ShowNoticeMessage("User \"%s\" is not authorized to use this device.", userName)			
 			fmt.Println()
 			
-			requestAccess = cbcli_utils.GetYesNoUserInput("Do you wish to request access to this device : ", false)			
+			requestAccess = cbcli_utils.GetYesNoUserInput("request-device-access", "Do you wish to request access to this device : ", false)			
 			if (requestAccess) {
 				if user, _ = deviceContext.GetGuestUser(userName); user == nil {
 					if user, err = deviceContext.NewGuestUser(userID, userName); err != nil {
@@ -265,6 +326,7 @@ func AuthorizeDeviceAndUser(config config.Config) error {
 		if len(owner.RSAPrivateKey) == 0 {
 			fmt.Println()
 
+			cbcli_utils.AssertInteractive("import-private-key", "import the owner's private key")
 			line := liner.NewLiner()
 			line.SetCtrlCAborts(true)
 			if ownerKey, err = ImportPrivateKey(line); err != nil {
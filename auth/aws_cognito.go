@@ -3,7 +3,6 @@ package auth
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"strconv"
 
 	"github.com/lestrrat-go/jwx/jwk"
@@ -37,11 +36,7 @@ func NewAWSCognitoJWT(config config.Config) (*AWSCognitoJWT, error) {
 
 	if awsJWT.jwkSet, err = jwk.Fetch(
 		context.Background(), 
-		fmt.Sprintf(
-			"https://cognito-idp.%s.amazonaws.com/%s/.well-known/jwks.json", 
-			cbcli_config.AWS_COGNITO_REGION, 
-			cbcli_config.AWS_COGNITO_USER_POOL_ID,
-		),
+		cbcli_config.OIDCJWKSURL,
 	); err != nil {
 		return nil, err
 	}
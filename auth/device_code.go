@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/mevansam/goutils/logger"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// amount the polling interval is increased by when the
+// token endpoint responds with 'slow_down'
+var slowDownIncrement = 5 * time.Second
+
+// response of the device authorization endpoint (RFC 8628 section 3.2)
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+// response of the token endpoint when polled
+// with a device code (RFC 8628 section 3.5)
+type deviceTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requests a device and user code from the
+// given device authorization endpoint
+func requestDeviceAuthorization(
+	client *http.Client,
+	deviceAuthURL string,
+	oauthConfig *oauth2.Config,
+) (*deviceAuthorization, error) {
+
+	var (
+		err error
+
+		resp *http.Response
+		body []byte
+	)
+
+	if len(deviceAuthURL) == 0 {
+		return nil, fmt.Errorf("a device authorization endpoint has not been configured")
+	}
+
+	params := url.Values{}
+	params.Set("scope", strings.Join(oauthConfig.Scopes, " "))
+	if resp, err = postForm(client, deviceAuthURL, oauthConfig, params); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"device authorization request failed with status %d: %s",
+			resp.StatusCode, strings.TrimSpace(string(body)),
+		)
+	}
+
+	da := &deviceAuthorization{}
+	if err = json.Unmarshal(body, da); err != nil {
+		return nil, err
+	}
+	if len(da.DeviceCode) == 0 || len(da.UserCode) == 0 || len(da.VerificationURI) == 0 {
+		return nil, fmt.Errorf("invalid device authorization response: %s", string(body))
+	}
+	if da.Interval == 0 {
+		da.Interval = 5
+	}
+	logger.TraceMessage("Device authorization response: %# v", da)
+
+	return da, nil
+}
+
+// polls the token endpoint until the user has completed
+// the authorization, denied it or the device code expires
+func pollDeviceAccessToken(
+	ctx context.Context,
+	client *http.Client,
+	oauthConfig *oauth2.Config,
+	da *deviceAuthorization,
+) (*oauth2.Token, error) {
+
+	var (
+		err error
+
+		resp *http.Response
+		body []byte
+	)
+
+	interval := time.Duration(da.Interval) * time.Second
+	if da.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(da.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", deviceCodeGrantType)
+	params.Set("device_code", da.DeviceCode)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("the device code expired before authorization was completed")
+		case <-time.After(interval):
+		}
+
+		if resp, err = postForm(client, oauthConfig.Endpoint.TokenURL, oauthConfig, params); err != nil {
+			return nil, err
+		}
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		tr := &deviceTokenResponse{}
+		if err = json.Unmarshal(body, tr); err != nil {
+			return nil, fmt.Errorf(
+				"invalid token response with status %d: %s",
+				resp.StatusCode, strings.TrimSpace(string(body)),
+			)
+		}
+		switch tr.Error {
+		case "":
+			if resp.StatusCode != http.StatusOK || len(tr.AccessToken) == 0 {
+				return nil, fmt.Errorf(
+					"invalid token response with status %d: %s",
+					resp.StatusCode, strings.TrimSpace(string(body)),
+				)
+			}
+			token := &oauth2.Token{
+				AccessToken:  tr.AccessToken,
+				TokenType:    tr.TokenType,
+				RefreshToken: tr.RefreshToken,
+			}
+			if tr.ExpiresIn > 0 {
+				token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+			}
+			// the id token is retrieved from the token's
+			// extra values as is done for tokens returned
+			// by the browser based authorization code flow
+			return token.WithExtra(map[string]interface{}{
+				"id_token": tr.IDToken,
+			}), nil
+
+		case "authorization_pending":
+			logger.TraceMessage("Device authorization is pending.")
+
+		case "slow_down":
+			interval += slowDownIncrement
+			logger.TraceMessage("Device token polling interval increased to %s.", interval)
+
+		case "access_denied":
+			return nil, fmt.Errorf("the authorization request was denied")
+
+		case "expired_token":
+			return nil, fmt.Errorf("the device code expired before authorization was completed")
+
+		default:
+			if len(tr.ErrorDescription) > 0 {
+				return nil, fmt.Errorf("%s: %s", tr.Error, tr.ErrorDescription)
+			}
+			return nil, fmt.Errorf("%s", tr.Error)
+		}
+	}
+}
+
+func postForm(
+	client *http.Client,
+	endpoint string,
+	oauthConfig *oauth2.Config,
+	params url.Values,
+) (*http.Response, error) {
+
+	var (
+		err error
+
+		req *http.Request
+	)
+
+	params.Set("client_id", oauthConfig.ClientID)
+	if req, err = http.NewRequest(http.MethodPost, endpoint, strings.NewReader(params.Encode())); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if len(oauthConfig.ClientSecret) > 0 {
+		req.SetBasicAuth(url.QueryEscape(oauthConfig.ClientID), url.QueryEscape(oauthConfig.ClientSecret))
+	}
+	return client.Do(req)
+}
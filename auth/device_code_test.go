@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// stand-in OIDC server that responds to device token
+// requests with the given sequence of responses
+type oidcServer struct {
+	*httptest.Server
+
+	mx        sync.Mutex
+	responses []map[string]interface{}
+	polls     int
+	pollTimes []time.Time
+}
+
+func newOIDCServer(t *testing.T, responses ...map[string]interface{}) *oidcServer {
+
+	s := &oidcServer{responses: responses}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unable to parse device authorization request: %s", err.Error())
+		}
+		if r.Form.Get("client_id") != "test-client" {
+			t.Errorf("unexpected client_id '%s'", r.Form.Get("client_id"))
+		}
+		if r.Form.Get("scope") != "openid profile" {
+			t.Errorf("unexpected scope '%s'", r.Form.Get("scope"))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"device_code":      "test-device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": s.URL + "/activate",
+			"expires_in":       600,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unable to parse token request: %s", err.Error())
+		}
+		if r.Form.Get("grant_type") != deviceCodeGrantType {
+			t.Errorf("unexpected grant_type '%s'", r.Form.Get("grant_type"))
+		}
+		if r.Form.Get("device_code") != "test-device-code" {
+			t.Errorf("unexpected device_code '%s'", r.Form.Get("device_code"))
+		}
+
+		s.mx.Lock()
+		defer s.mx.Unlock()
+
+		s.pollTimes = append(s.pollTimes, time.Now())
+		if s.polls >= len(s.responses) {
+			t.Errorf("unexpected token request #%d", s.polls+1)
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"error": "invalid_request"})
+			return
+		}
+		response := s.responses[s.polls]
+		s.polls++
+
+		status := http.StatusOK
+		if _, isError := response["error"]; isError {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, response)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *oidcServer) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID: "test-client",
+		Scopes:   []string{"openid", "profile"},
+		Endpoint: oauth2.Endpoint{
+			TokenURL: s.URL + "/token",
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func pollTestServer(t *testing.T, s *oidcServer) (*oauth2.Token, error) {
+
+	oauthConfig := s.oauthConfig()
+	da, err := requestDeviceAuthorization(s.Client(), s.URL+"/device", oauthConfig)
+	if err != nil {
+		t.Fatalf("device authorization request failed: %s", err.Error())
+	}
+	if da.UserCode != "ABCD-EFGH" || da.Interval != 5 {
+		t.Fatalf("unexpected device authorization: %# v", da)
+	}
+	// poll without waiting between requests
+	da.Interval = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return pollDeviceAccessToken(ctx, s.Client(), oauthConfig, da)
+}
+
+func TestDeviceCodeSuccess(t *testing.T) {
+
+	s := newOIDCServer(t,
+		map[string]interface{}{
+			"access_token":  "test-access-token",
+			"token_type":    "Bearer",
+			"refresh_token": "test-refresh-token",
+			"id_token":      "test-id-token",
+			"expires_in":    3600,
+		},
+	)
+	token, err := pollTestServer(t, s)
+	if err != nil {
+		t.Fatalf("expected a token but got error: %s", err.Error())
+	}
+	if token.AccessToken != "test-access-token" || token.RefreshToken != "test-refresh-token" {
+		t.Errorf("unexpected token: %# v", token)
+	}
+	if idToken, _ := token.Extra("id_token").(string); idToken != "test-id-token" {
+		t.Errorf("expected id token 'test-id-token' but got '%s'", idToken)
+	}
+	if time.Until(token.Expiry) < 59*time.Minute {
+		t.Errorf("unexpected token expiry %s", token.Expiry)
+	}
+}
+
+func TestDeviceCodeAuthorizationPending(t *testing.T) {
+
+	s := newOIDCServer(t,
+		map[string]interface{}{"error": "authorization_pending"},
+		map[string]interface{}{"error": "authorization_pending"},
+		map[string]interface{}{"access_token": "test-access-token", "token_type": "Bearer"},
+	)
+	token, err := pollTestServer(t, s)
+	if err != nil {
+		t.Fatalf("expected a token but got error: %s", err.Error())
+	}
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("unexpected access token '%s'", token.AccessToken)
+	}
+	if s.polls != 3 {
+		t.Errorf("expected 3 token requests but got %d", s.polls)
+	}
+}
+
+func TestDeviceCodeSlowDown(t *testing.T) {
+
+	defer func(increment time.Duration) {
+		slowDownIncrement = increment
+	}(slowDownIncrement)
+	slowDownIncrement = 200 * time.Millisecond
+
+	s := newOIDCServer(t,
+		map[string]interface{}{"error": "slow_down"},
+		map[string]interface{}{"error": "slow_down"},
+		map[string]interface{}{"access_token": "test-access-token", "token_type": "Bearer"},
+	)
+	if _, err := pollTestServer(t, s); err != nil {
+		t.Fatalf("expected a token but got error: %s", err.Error())
+	}
+	if s.polls != 3 {
+		t.Fatalf("expected 3 token requests but got %d", s.polls)
+	}
+	// the interval is increased on each slow_down response
+	if d := s.pollTimes[1].Sub(s.pollTimes[0]); d < slowDownIncrement {
+		t.Errorf("expected the second poll to wait at least %s but it waited %s", slowDownIncrement, d)
+	}
+	if d := s.pollTimes[2].Sub(s.pollTimes[1]); d < 2*slowDownIncrement {
+		t.Errorf("expected the third poll to wait at least %s but it waited %s", 2*slowDownIncrement, d)
+	}
+}
+
+func TestDeviceCodeExpiredToken(t *testing.T) {
+
+	s := newOIDCServer(t,
+		map[string]interface{}{"error": "authorization_pending"},
+		map[string]interface{}{"error": "expired_token"},
+	)
+	_, err := pollTestServer(t, s)
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected an expired device code error but got: %v", err)
+	}
+	if s.polls != 2 {
+		t.Errorf("expected 2 token requests but got %d", s.polls)
+	}
+}
+
+func TestDeviceCodeAccessDenied(t *testing.T) {
+
+	s := newOIDCServer(t,
+		map[string]interface{}{"error": "access_denied"},
+	)
+	_, err := pollTestServer(t, s)
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Fatalf("expected an access denied error but got: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var AgentCommands = &cobra.Command{
+	Use: "agent",
+
+	Short: "Manage the passphrase agent.",
+	Long: `
+The passphrase agent holds the passphrase that unlocks the CLI
+configuration in memory so that it does not need to be entered each
+time the CLI is run. Much like the ssh-agent the passphrase is only
+accessible via a socket that is restricted to the current user. The
+passphrase is discarded once the unlock timeout set via 'cb init'
+expires after which it will be requested again on the next command.
+`,
+
+	// agent commands do not require
+	// an initialized and authorized
+	// configuration
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+func init() {
+	AgentCommands.AddCommand(startCommand)
+	AgentCommands.AddCommand(serveCommand)
+	AgentCommands.AddCommand(statusCommand)
+	AgentCommands.AddCommand(lockCommand)
+	AgentCommands.AddCommand(stopCommand)
+}
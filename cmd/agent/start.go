@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_agent "github.com/appbricks/cloud-builder-cli/agent"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+// passphrase and unlock timeout passed
+// to the agent process via its stdin
+type agentSeed struct {
+	Passphrase string `json:"passphrase"`
+	Timeout    int64  `json:"timeout"`
+}
+
+var startCommand = &cobra.Command{
+	Use: "start",
+
+	Short: "Start the passphrase agent.",
+	Long: `
+Starts the passphrase agent in the background and unlocks it with the
+passphrase entered to unlock the configuration. The agent will retain
+the passphrase for the unlock timeout configured via 'cb init'.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		StartAgent()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+var serveCommand = &cobra.Command{
+	Use: "serve",
+
+	Short: "Run the passphrase agent process.",
+	Long: `
+Runs the passphrase agent process. This command is invoked by
+'cb agent start' and should not be run directly.
+`,
+	Hidden: true,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ServeAgent()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func StartAgent() {
+
+	var (
+		err error
+
+		executable string
+		stdin      io.WriteCloser
+		seed       []byte
+	)
+
+	if cbcli_agent.IsRunning() {
+		fmt.Println()
+		cbcli_utils.ShowNoticeMessage("The passphrase agent is already running.")
+		fmt.Println()
+		return
+	}
+	if !cbcli_config.Config.Initialized() || len(cbcli_config.Passphrase) == 0 {
+		cbcli_utils.ShowErrorAndExit("The configuration has not been secured with a passphrase. Please run 'cb init'.")
+	}
+	timeout := cbcli_config.Config.GetKeyTimeout()
+	if timeout <= 0 {
+		cbcli_utils.ShowErrorAndExit("An unlock timeout has not been configured. Please run 'cb init' to set one.")
+	}
+
+	if executable, err = os.Executable(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	agentCmd := exec.Command(executable, "agent", "serve")
+	cbcli_agent.Detach(agentCmd)
+	if stdin, err = agentCmd.StdinPipe(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = agentCmd.Start(); err != nil {
+		logger.DebugMessage("StartAgent(): Failed to start agent process: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("Unable to start the passphrase agent.")
+	}
+	if seed, err = json.Marshal(&agentSeed{
+		Passphrase: cbcli_config.Passphrase,
+		Timeout:    int64(timeout / time.Second),
+	}); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	_, err = stdin.Write(seed)
+	stdin.Close()
+	if err != nil {
+		logger.DebugMessage("StartAgent(): Failed to pass passphrase to agent process: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("Unable to start the passphrase agent.")
+	}
+	// the agent process is not waited on
+	// as it continues to run in the background
+	_ = agentCmd.Process.Release()
+
+	// wait for agent to start listening
+	for i := 0; i < 50 && !cbcli_agent.IsRunning(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !cbcli_agent.IsRunning() {
+		cbcli_utils.ShowErrorAndExit("The passphrase agent did not start.")
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage(
+		"The passphrase agent has been started and will retain the passphrase for %s.",
+		timeout.String(),
+	)
+	fmt.Println()
+}
+
+func ServeAgent() {
+
+	var (
+		err error
+
+		seed agentSeed
+	)
+
+	if err = json.NewDecoder(os.Stdin).Decode(&seed); err != nil {
+		logger.ErrorMessage("ServeAgent(): Failed to read agent seed: %s", err.Error())
+		os.Exit(1)
+	}
+	os.Stdin.Close()
+
+	agent := cbcli_agent.NewAgent(cbcli_agent.SocketPath())
+	if err = agent.Listen(); err != nil {
+		logger.ErrorMessage("ServeAgent(): Failed to listen on agent socket: %s", err.Error())
+		os.Exit(1)
+	}
+	agent.Unlock(seed.Passphrase, time.Duration(seed.Timeout)*time.Second)
+	seed.Passphrase = ""
+
+	if err = agent.Serve(); err != nil {
+		logger.ErrorMessage("ServeAgent(): Agent exited with error: %s", err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	cbcli_agent "github.com/appbricks/cloud-builder-cli/agent"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var statusCommand = &cobra.Command{
+	Use: "status",
+
+	Short: "Show the status of the passphrase agent.",
+	Long: `
+Shows whether the passphrase agent is running and if it is holding
+the passphrase that unlocks the configuration.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		AgentStatus()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+var lockCommand = &cobra.Command{
+	Use: "lock",
+
+	Short: "Discard the passphrase held by the agent.",
+	Long: `
+Discards the passphrase held by the passphrase agent without stopping
+the agent. The passphrase will be requested on the next command and
+retained by the agent once again.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cbcli_agent.Lock(); err != nil {
+			cbcli_utils.ShowErrorAndExit("The passphrase agent is not running.")
+		}
+		fmt.Println()
+		cbcli_utils.ShowNoteMessage("The passphrase agent has been locked.")
+		fmt.Println()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+var stopCommand = &cobra.Command{
+	Use: "stop",
+
+	Short: "Stop the passphrase agent.",
+	Long: `
+Stops the passphrase agent discarding the passphrase it holds.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cbcli_agent.Stop(); err != nil {
+			cbcli_utils.ShowErrorAndExit("The passphrase agent is not running.")
+		}
+		fmt.Println()
+		cbcli_utils.ShowNoteMessage("The passphrase agent has been stopped.")
+		fmt.Println()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func AgentStatus() {
+
+	var (
+		err error
+
+		unlocked  bool
+		expiresAt time.Time
+	)
+
+	fmt.Println()
+	if unlocked, expiresAt, err = cbcli_agent.Status(); err != nil {
+		cbcli_utils.ShowNoticeMessage("The passphrase agent is not running.")
+	} else if unlocked {
+		cbcli_utils.ShowNoteMessage(
+			"The passphrase agent is running and unlocked until %s.",
+			expiresAt.Local().Format(time.RFC1123),
+		)
+	} else {
+		cbcli_utils.ShowNoteMessage("The passphrase agent is running but is locked.")
+	}
+	fmt.Println()
+}
@@ -51,10 +51,11 @@ func DeleteRecipe(cookbookName string) {
 	}
 
 	fmt.Println()
-	response := cbcli_utils.GetUserInput(
+	if cbcli_utils.GetConfirmationInput(
+		"confirm-delete-cookbook",
 		"Confirm deletion by entering the cookbook name: ",
-	)
-	if response == cookbookName {
+		cookbookName,
+	) {
 		if err := cookbook.DeleteImportedCookbook(cookbookName); err != nil {
 			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
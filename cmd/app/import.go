@@ -47,7 +47,7 @@ func ImportRecipe(args []string) {
 	if len(args) == 0 {
 		fmt.Println()
 		importZipFile = strings.Trim(
-			cbcli_utils.GetUserInput("Path to cookbook zip file to import (you can drag/drop from a finder/explorer window to the terminal) : "),
+			cbcli_utils.GetUserInput("cookbook-zip-file", "Path to cookbook zip file to import (you can drag/drop from a finder/explorer window to the terminal) : "),
 			" '\"",
 		)
 
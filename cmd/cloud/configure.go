@@ -49,6 +49,7 @@ func ConfigureCloud(name string) {
 		if inputForm, err = provider.InputForm(); err != nil {
 			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
+		cbcli_utils.AssertInteractive("provider-configuration", "configure cloud provider")
 		if err = ux.GetFormInput(inputForm,
 			"Cloud Provider Configuration",
 			"CONFIGURATION DATA INPUT",
@@ -12,6 +12,7 @@ import (
 
 	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
 	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
 )
 
 var listFlags = struct {
@@ -32,7 +33,9 @@ the correct permissions.
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		if listFlags.region {
+		if !cbcli_utils.IsTableOutput() {
+			renderCloudsOutput()
+		} else if listFlags.region {
 			ListCloudsByRegion()
 		} else {
 			ListClouds()
@@ -40,6 +43,21 @@ the correct permissions.
 	},
 }
 
+// structured output schema for a cloud. the
+// schema is documented in doc/output.md and
+// fields should only be added to it.
+type cloudOutput struct {
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description" yaml:"description"`
+	Configured  bool           `json:"configured" yaml:"configured"`
+	Regions     []regionOutput `json:"regions" yaml:"regions"`
+}
+
+type regionOutput struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
 func ListClouds() {
 
 	cloudList := cbcli_config.Config.TargetContext().CloudProviderTemplates()
@@ -98,6 +116,28 @@ func ListCloudsByRegion() {
 	}
 }
 
+func renderCloudsOutput() {
+
+	output := []cloudOutput{}
+	for _, cp := range cbcli_config.Config.TargetContext().CloudProviderTemplates() {
+
+		cloud := cloudOutput{
+			Name:        cp.Name(),
+			Description: cp.Description(),
+			Configured:  cp.IsValid(),
+			Regions:     []regionOutput{},
+		}
+		for _, r := range cp.GetRegions() {
+			cloud.Regions = append(cloud.Regions, regionOutput{
+				Name:        r.Name,
+				Description: r.Description,
+			})
+		}
+		output = append(output, cloud)
+	}
+	cbcli_utils.RenderOutput(output)
+}
+
 func init() {
 	flags := listCommand.Flags()
 	flags.SortFlags = false
@@ -16,11 +16,14 @@ package cmd
 // along with this program. If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"syscall"
 
 	"github.com/gookit/color"
@@ -32,11 +35,15 @@ import (
 	"github.com/mevansam/goutils/logger"
 	"github.com/mevansam/goutils/run"
 
+	agent_cmd "github.com/appbricks/cloud-builder-cli/cmd/agent"
 	"github.com/appbricks/cloud-builder-cli/cmd/app"
 	"github.com/appbricks/cloud-builder-cli/cmd/cloud"
+	config_cmd "github.com/appbricks/cloud-builder-cli/cmd/config"
 	"github.com/appbricks/cloud-builder-cli/cmd/device"
 	"github.com/appbricks/cloud-builder-cli/cmd/initialize"
+	"github.com/appbricks/cloud-builder-cli/cmd/key"
 	"github.com/appbricks/cloud-builder-cli/cmd/recipe"
+	"github.com/appbricks/cloud-builder-cli/cmd/scheduler"
 	"github.com/appbricks/cloud-builder-cli/cmd/space"
 	"github.com/appbricks/cloud-builder-cli/cmd/target"
 	"github.com/appbricks/cloud-builder/config"
@@ -45,6 +52,7 @@ import (
 	"github.com/appbricks/mycloudspace-client/mycscloud"
 	"github.com/appbricks/mycloudspace-common/monitors"
 
+	cbcli_agent "github.com/appbricks/cloud-builder-cli/agent"
 	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
 	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
 	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
@@ -52,8 +60,14 @@ import (
 )
 
 var (
-	isProd  string
-	cfgFile string
+	isProd string
+
+	// passphrase retrieved from the
+	// passphrase agent if it is running
+	agentPassphrase string
+
+	// embedded cookbook
+	cbCookbook *cookbook.Cookbook
 )
 
 // commands that do not require
@@ -62,7 +76,21 @@ var noauthCmds = map[string]bool{
 	"help": true,
 	"version": true,
 	"init": true,
+	"login": true,
 	"logout": true,
+	"whoami": true,
+	// recovers the owner's key which is
+	// required to authorize the owner
+	"key recover": true,
+}
+
+// commands that do not load
+// the configuration
+var noconfigCmds = map[string]bool{
+	"agent serve": true,
+	"agent status": true,
+	"agent lock": true,
+	"agent stop": true,
 }
 
 var spaceCmds = map[string]bool{
@@ -99,6 +127,7 @@ anonymized as it traverses the public provider networks.
 			fmt.Println(color.FgBlue.Render(`https://appbricks.io/legal/`))
 
 			response := cbcli_utils.GetUserInputFromList(
+				"accept-eula",
 				"Do you agree to the terms: ",
 				"yes",
 				[]string{"no", "yes"},
@@ -118,6 +147,10 @@ anonymized as it traverses the public provider networks.
 			cmdName = cmd.Parent().Name()
 		}
 
+		if cmd.Parent() != nil && noauthCmds[cmd.Parent().Name()+" "+cmd.Name()] {
+			cmdName = cmd.Parent().Name()+" "+cmd.Name()
+		}
+
 		if _, noauth := noauthCmds[cmdName]; !noauth {
 			if !cbcli_config.Config.Initialized() {
 				fmt.Println(
@@ -165,7 +198,7 @@ anonymized as it traverses the public provider networks.
 				} else {
 					// show logged in message only if cli 
 					// is being run via a non-root user				
-					if isAdmin, _ := run.IsAdmin(); !isAdmin {
+					if isAdmin, _ := run.IsAdmin(); !isAdmin && cbcli_utils.IsTableOutput() {
 						fmt.Println()
 						deviceName, _ := cbcli_config.Config.DeviceContext().GetDeviceName()
 						cbcli_utils.ShowNoticeMessage(
@@ -175,12 +208,16 @@ anonymized as it traverses the public provider networks.
 					// load space target nodes if 
 					// executing a target command
 					if _, isSpaceCmd := spaceCmds[cmdName]; isSpaceCmd {
-						fmt.Printf("Loading space targets...\r")
+						if cbcli_utils.IsTableOutput() {
+							fmt.Printf("Loading space targets...\r")
+						}
 						if cbcli_config.SpaceNodes, err = mycscloud.GetSpaceNodes(cbcli_config.Config, cbcli_config.AWS_USERSPACE_API_URL); err != nil {
 							logger.DebugMessage("Failed to load and merge remote space nodes with local targets: %s", err.Error())
 							cbcli_utils.ShowErrorAndExit("Failed to load user's space nodes.")
 						}
-						fmt.Printf("                        \r")
+						if cbcli_utils.IsTableOutput() {
+							fmt.Printf("                        \r")
+						}
 					}
 				}
 			}
@@ -209,6 +246,9 @@ func Execute() {
 		if profilingEnabled {
 			pprof.StopCPUProfile()
 		}
+		if cbcli_utils.ExitCode != 0 {
+			os.Exit(cbcli_utils.ExitCode)
+		}
 	}()
 
 	if isProd == "yes" {
@@ -227,6 +267,8 @@ func Execute() {
 		}
 		
 	} else {		
+		cbcli_config.OverrideOIDCEndpoints()
+
 		profileFileName := os.Getenv("CBS_PROFILE_FILE")
 		if len(profileFileName) > 0 {
 			if profileFile, err = os.Create(profileFileName); err != nil {
@@ -252,6 +294,7 @@ func Execute() {
 			if err = cbcli_config.Config.Save(); err != nil {
 				cbcli_utils.ShowErrorAndExit(err.Error())
 			}
+			updateAgent()
 		}
 	}
 }
@@ -268,12 +311,27 @@ func init() {
 	setupCloseHandler()
 
 	if systemPassphrase := os.Getenv("CBS_SYSTEM_PASSPHRASE"); len(systemPassphrase) > 0 {
+		cbcli_config.Passphrase = systemPassphrase
 		config.SystemPassphrase = func() string {
 			return systemPassphrase
 		}
+	} else if os.Getenv("__CB_PASSPHRASE_STDIN__") == "1" {
+		// passphrase handed to a background
+		// process spawned by the CLI
+		os.Unsetenv("__CB_PASSPHRASE_STDIN__")
+		if systemPassphrase, err = bufio.NewReader(os.Stdin).ReadString('\n'); err != nil && err != io.EOF {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		if systemPassphrase = strings.TrimRight(systemPassphrase, "\r\n"); len(systemPassphrase) > 0 {
+			cbcli_config.Passphrase = systemPassphrase
+			config.SystemPassphrase = func() string {
+				return systemPassphrase
+			}
+		}
 	}
+	cbcli_config.ReloadConfig = reloadConfig
 
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(cbcli_utils.InitOutput, cbcli_utils.InitInput, initConfig)
 	cobra.EnableCommandSorting = false
 	addCommands()
 
@@ -283,7 +341,17 @@ func init() {
 		cbcli_utils.ShowErrorAndExit(err.Error())
 	}
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", filepath.Join(home, ".cb", "config.yml"), "config file")
+	rootCmd.PersistentFlags().StringVar(&cbcli_config.ConfigFile, "config", filepath.Join(home, ".cb", "config.yml"), "config file")
+	rootCmd.PersistentFlags().StringVarP(&cbcli_utils.OutputFormat, "output", "o", cbcli_utils.OutputTable, 
+		"output format of list and show commands (table, json or yaml)")
+	rootCmd.PersistentFlags().BoolVar(&cbcli_auth.DeviceCodeLogin, "device-code", false, 
+		"login by entering a code in a browser on another device\n(for use over SSH or on headless hosts)")
+	rootCmd.PersistentFlags().BoolVar(&cbcli_utils.NonInteractive, "non-interactive", false, 
+		"do not prompt for input and fail if an answer for a prompt has not been provided")
+	rootCmd.PersistentFlags().BoolVarP(&cbcli_utils.AssumeYes, "yes", "y", false, 
+		"answer 'yes' to all confirmation prompts (implies --non-interactive)")
+	rootCmd.PersistentFlags().StringVar(&cbcli_utils.AnswersFile, "answers", "", 
+		"yaml file of answers keyed by prompt ID (implies --non-interactive)")
 }
 
 // read in config file and ENV variables if set.
@@ -291,22 +359,44 @@ func initConfig() {
 
 	var (
 		err error
-
-		cbCookbook *cookbook.Cookbook
 	)
 
+	if cmd, _, err := rootCmd.Find(os.Args[1:]); err == nil && 
+		cmd.Parent() != nil && noconfigCmds[cmd.Parent().Name()+" "+cmd.Name()] {
+		return
+	}
+
+	// ask the passphrase agent for the passphrase
+	// before prompting the user for it
+	if config.SystemPassphrase == nil {
+		var unlocked bool
+		if agentPassphrase, unlocked = cbcli_agent.GetPassphrase(); unlocked {
+			cbcli_config.Passphrase = agentPassphrase
+			config.SystemPassphrase = func() string {
+				return agentPassphrase
+			}
+		}
+	}
+
 	// load embedded cookbook
 	if cbCookbook, err = cbcli_cookbook.NewCookbook(); err != nil {
 		cbcli_utils.ShowErrorAndExit(err.Error())
 	}
 	// initialize / load config file
-	if cbcli_config.Config, err = config.InitFileConfig(cfgFile, cbCookbook, getPassphrase, uploadConfig); err != nil {
+	if cbcli_config.Config, err = config.InitFileConfig(cbcli_config.ConfigFile, cbCookbook, getPassphrase, uploadConfig); err != nil {
 		cbcli_utils.ShowErrorAndExit(err.Error())
 	}
 	if err = cbcli_config.Config.Load(); err != nil {
 		logger.DebugMessage("Error loading the configuration: %s", err.Error())
 
-		fmt.Println("Failed to unlock configuration file!")
+		if len(agentPassphrase) > 0 {
+			// passphrase held by the agent is no longer valid
+			// so discard it to ensure the user is prompted
+			_ = cbcli_agent.Lock()
+			fmt.Println("Failed to unlock configuration file with the passphrase held by the agent! The agent has been locked.")
+		} else {
+			fmt.Println("Failed to unlock configuration file!")
+		}
 		os.Exit(1)
 	}
 
@@ -324,6 +414,31 @@ func initConfig() {
 	}
 }
 
+// reloads the configuration from file
+func reloadConfig() error {
+
+	var (
+		err error
+
+		cfg config.Config
+	)
+
+	if cfg, err = config.InitFileConfig(
+		cbcli_config.ConfigFile, cbCookbook,
+		func() string {
+			return cbcli_config.Passphrase
+		},
+		uploadConfig,
+	); err != nil {
+		return err
+	}
+	if err = cfg.Load(); err != nil {
+		return err
+	}
+	cbcli_config.Config = cfg
+	return nil
+}
+
 // get encryption passphrase from use input
 func getPassphrase() string {
 
@@ -333,6 +448,14 @@ func getPassphrase() string {
 		passphrase string
 	)
 
+	if cbcli_utils.NonInteractive {
+		cbcli_config.Passphrase = cbcli_utils.LookupAnswer(
+			"config-passphrase",
+			"Please enter the passphrase to unlock the configuration",
+		)
+		return cbcli_config.Passphrase
+	}
+
 	line := liner.NewLiner()
 	defer func() {
 		line.Close()
@@ -353,9 +476,29 @@ func getPassphrase() string {
 	if passphrase, err = line.PasswordPrompt("Please enter the passphrase to unlock the configuration : "); err != nil {
 		panic(err)
 	}
+	cbcli_config.Passphrase = passphrase
 	return passphrase
 }
 
+// hands the passphrase the configuration was unlocked with
+// to the passphrase agent if it is running and it does not
+// already hold it. the agent will retain the passphrase
+// for the unlock timeout.
+func updateAgent() {
+
+	if len(cbcli_config.Passphrase) == 0 || 
+		cbcli_config.Passphrase == agentPassphrase || 
+		!cbcli_agent.IsRunning() {
+		return
+	}
+	if err := cbcli_agent.SetPassphrase(
+		cbcli_config.Passphrase, 
+		cbcli_config.Config.GetKeyTimeout(),
+	); err != nil {
+		logger.DebugMessage("Failed to hand passphrase to the agent: %s", err.Error())
+	}
+}
+
 // encrypt and upload configuration to cloud
 func uploadConfig(key string, configData []byte, asOf int64) (int64, error) {
 
@@ -391,11 +534,17 @@ func setupCloseHandler() {
 func addCommands() {
 	rootCmd.AddCommand(versionCommand)
 	rootCmd.AddCommand(initialize.InitCommand)
+	rootCmd.AddCommand(loginCommand)
 	rootCmd.AddCommand(logoutCommand)
+	rootCmd.AddCommand(whoamiCommand)
+	rootCmd.AddCommand(agent_cmd.AgentCommands)
+	rootCmd.AddCommand(config_cmd.ConfigCommands)
+	rootCmd.AddCommand(key.KeyCommands)
 	rootCmd.AddCommand(cloud.CloudCommands)
 	rootCmd.AddCommand(device.DeviceCommands)
 	rootCmd.AddCommand(app.CookbookCommands)
 	rootCmd.AddCommand(recipe.RecipeCommands)
 	rootCmd.AddCommand(target.TargetCommands)
 	rootCmd.AddCommand(space.SpaceCommands)
+	rootCmd.AddCommand(scheduler.SchedulerCommands)
 }
@@ -0,0 +1,341 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/mevansam/goutils/crypto"
+
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+)
+
+const (
+	// version 1 archives contain only the target
+	// context. version 2 archives contain the
+	// target context and the target settings.
+	archiveVersion = 2
+
+	encryptionPassphrase = "passphrase"
+	encryptionRSA        = "rsa"
+)
+
+// configuration archive written by 'cb config export'.
+// the configuration data is encrypted with AES-GCM using
+// a random key which is either derived from a passphrase
+// using scrypt or encrypted with an RSA public key.
+type configArchive struct {
+	Version    int    `json:"version"`
+	Encryption string `json:"encryption"`
+
+	// scrypt salt if encrypted with a passphrase
+	Salt []byte `json:"salt,omitempty"`
+	// RSA-OAEP encrypted data key if encrypted with
+	// an RSA key along with the public key's SHA256
+	// fingerprint which is used to verify the key
+	// provided for decryption
+	Key         []byte `json:"key,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// contents of the encrypted data of an archive
+type archivePayload struct {
+	Config         []byte                                  `json:"config"`
+	TargetSettings map[string]*cbcli_config.TargetSettings `json:"targetSettings,omitempty"`
+}
+
+func newArchivePayload(config []byte, targetSettings map[string]*cbcli_config.TargetSettings) ([]byte, error) {
+	return json.Marshal(&archivePayload{
+		Config:         config,
+		TargetSettings: targetSettings,
+	})
+}
+
+// returns the payload of the archive given its decrypted data
+func (a *configArchive) payload(data []byte) (*archivePayload, error) {
+
+	payload := &archivePayload{}
+	if a.Version == 1 {
+		payload.Config = data
+		return payload, nil
+	}
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, fmt.Errorf("the configuration archive data is not valid")
+	}
+	return payload, nil
+}
+
+func newPassphraseArchive(data []byte, passphrase string) (*configArchive, error) {
+
+	var (
+		err error
+
+		key []byte
+	)
+
+	archive := &configArchive{
+		Version:    archiveVersion,
+		Encryption: encryptionPassphrase,
+		Salt:       make([]byte, 32),
+	}
+	if _, err = io.ReadFull(rand.Reader, archive.Salt); err != nil {
+		return nil, err
+	}
+	if key, err = passphraseKey(passphrase, archive.Salt); err != nil {
+		return nil, err
+	}
+	if err = archive.seal(key, data); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func newRSAArchive(data []byte, publicKey *rsa.PublicKey) (*configArchive, error) {
+
+	var (
+		err error
+	)
+
+	archive := &configArchive{
+		Version:    archiveVersion,
+		Encryption: encryptionRSA,
+	}
+
+	key := make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if archive.Key, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, key, []byte("cb-config")); err != nil {
+		return nil, err
+	}
+	if archive.Fingerprint, err = publicKeyFingerprint(publicKey); err != nil {
+		return nil, err
+	}
+	if err = archive.seal(key, data); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func readArchive(archiveFile string) (*configArchive, error) {
+
+	var (
+		err error
+
+		data []byte
+	)
+
+	if data, err = os.ReadFile(archiveFile); err != nil {
+		return nil, err
+	}
+	archive := &configArchive{}
+	if err = json.Unmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid configuration archive", archiveFile)
+	}
+	if archive.Version < 1 || archive.Version > archiveVersion {
+		return nil, fmt.Errorf("configuration archive version %d is not supported", archive.Version)
+	}
+	return archive, nil
+}
+
+func (a *configArchive) write(archiveFile string) error {
+
+	var (
+		err error
+
+		data []byte
+	)
+
+	if data, err = json.MarshalIndent(a, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(archiveFile, data, 0600)
+}
+
+func (a *configArchive) decryptWithPassphrase(passphrase string) ([]byte, error) {
+
+	var (
+		err error
+
+		key []byte
+	)
+
+	if a.Encryption != encryptionPassphrase {
+		return nil, fmt.Errorf("configuration archive is not encrypted with a passphrase")
+	}
+	if key, err = passphraseKey(passphrase, a.Salt); err != nil {
+		return nil, err
+	}
+	return a.open(key)
+}
+
+func (a *configArchive) decryptWithRSAKey(privateKey *rsa.PrivateKey) ([]byte, error) {
+
+	var (
+		err error
+
+		fingerprint string
+		key         []byte
+	)
+
+	if a.Encryption != encryptionRSA {
+		return nil, fmt.Errorf("configuration archive is not encrypted with an RSA key")
+	}
+	if fingerprint, err = publicKeyFingerprint(&privateKey.PublicKey); err != nil {
+		return nil, err
+	}
+	if fingerprint != a.Fingerprint {
+		return nil, fmt.Errorf("the given key is not the key the configuration archive was encrypted with")
+	}
+	if key, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, a.Key, []byte("cb-config")); err != nil {
+		return nil, err
+	}
+	return a.open(key)
+}
+
+func (a *configArchive) seal(key, data []byte) error {
+
+	var (
+		err error
+
+		aead cipher.AEAD
+	)
+
+	if aead, err = newAEAD(key); err != nil {
+		return err
+	}
+	a.Nonce = make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, a.Nonce); err != nil {
+		return err
+	}
+	a.Data = aead.Seal(nil, a.Nonce, data, []byte(a.Encryption))
+	return nil
+}
+
+func (a *configArchive) open(key []byte) ([]byte, error) {
+
+	var (
+		err error
+
+		aead cipher.AEAD
+		data []byte
+	)
+
+	if aead, err = newAEAD(key); err != nil {
+		return nil, err
+	}
+	if data, err = aead.Open(nil, a.Nonce, a.Data, []byte(a.Encryption)); err != nil {
+		return nil, fmt.Errorf("unable to decrypt the configuration archive")
+	}
+	return data, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+
+	var (
+		err error
+
+		block cipher.Block
+	)
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func publicKeyFingerprint(publicKey *rsa.PublicKey) (string, error) {
+
+	var (
+		err error
+
+		der []byte
+	)
+
+	if der, err = x509.MarshalPKIXPublicKey(publicKey); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(der)), nil
+}
+
+// reads an RSA public key from a PEM file. if the file
+// contains a private key its public key is returned.
+func readRSAPublicKey(keyFile string, passphrase func() string) (*rsa.PublicKey, error) {
+
+	var (
+		err error
+
+		data      []byte
+		block     *pem.Block
+		publicKey interface{}
+	)
+
+	if data, err = os.ReadFile(keyFile); err != nil {
+		return nil, err
+	}
+	if block, _ = pem.Decode(data); block == nil {
+		return nil, fmt.Errorf("'%s' is not a PEM encoded key file", keyFile)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		if publicKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, err
+		}
+		if rsaPublicKey, ok := publicKey.(*rsa.PublicKey); ok {
+			return rsaPublicKey, nil
+		}
+		return nil, fmt.Errorf("'%s' is not an RSA public key", keyFile)
+
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+
+	default:
+		privateKey, err := readRSAPrivateKey(keyFile, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return &privateKey.PublicKey, nil
+	}
+}
+
+// reads an RSA private key from a PEM file. the passphrase
+// callback is used to retrieve the passphrase the key file
+// is encrypted with.
+func readRSAPrivateKey(keyFile string, passphrase func() string) (*rsa.PrivateKey, error) {
+
+	var (
+		err error
+
+		key    *crypto.RSAKey
+		keyPEM string
+		block  *pem.Block
+	)
+
+	if key, err = crypto.NewRSAKeyFromFile(keyFile, []byte(passphrase())); err != nil {
+		return nil, fmt.Errorf("unable to read key file '%s': %s", keyFile, err.Error())
+	}
+	if keyPEM, err = key.GetPrivateKeyPEM(); err != nil {
+		return nil, err
+	}
+	if block, _ = pem.Decode([]byte(keyPEM)); block == nil {
+		return nil, fmt.Errorf("'%s' is not a valid private key file", keyFile)
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
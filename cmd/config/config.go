@@ -0,0 +1,24 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ConfigCommands = &cobra.Command{
+	Use: "config",
+
+	Short: "Manage the CLI configuration.",
+	Long: `
+The CLI configuration consists of the cloud provider templates,
+recipe defaults and targets configured by the device owner. The
+sub-commands below allow you to export this configuration to an
+encrypted archive, restore it from such an archive and change the
+passphrase the configuration is encrypted with.
+`,
+}
+
+func init() {
+	ConfigCommands.AddCommand(exportCommand)
+	ConfigCommands.AddCommand(importCommand)
+	ConfigCommands.AddCommand(passphraseCommand)
+}
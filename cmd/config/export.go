@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var exportFlags = struct {
+	keyFile string
+}{}
+
+var exportCommand = &cobra.Command{
+	Use: "export [archive file]",
+
+	Short: "Export the configuration to an encrypted archive.",
+	Long: `
+Exports the cloud provider templates, recipe defaults and targets,
+along with each target's schedule, idle policy and pinned SSH host
+keys, to an encrypted archive. The archive is encrypted with a
+passphrase or, if the '--key' option is provided, with an RSA public
+key. The archive can be restored on this or any other device using
+'cb config import'.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ExportConfig(args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func ExportConfig(archiveFile string) {
+
+	var (
+		err error
+
+		config    bytes.Buffer
+		settings  map[string]*cbcli_config.TargetSettings
+		data      []byte
+		publicKey *rsa.PublicKey
+		archive   *configArchive
+	)
+
+	if err = cbcli_config.Config.TargetContext().Save(&config); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if settings, err = cbcli_config.AllTargetSettings(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if data, err = newArchivePayload(config.Bytes(), settings); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	if len(exportFlags.keyFile) > 0 {
+		if publicKey, err = readRSAPublicKey(exportFlags.keyFile, getKeyFilePassphrase); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		if archive, err = newRSAArchive(data, publicKey); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+
+	} else {
+		fmt.Println()
+		passphrase := cbcli_utils.GetPasswordInput(
+			"archive-passphrase",
+			"Enter a passphrase to encrypt the archive with : ",
+		)
+		if !cbcli_utils.NonInteractive &&
+			passphrase != cbcli_utils.GetPasswordInput("archive-passphrase", "Confirm the passphrase : ") {
+			cbcli_utils.ShowErrorAndExit("The passphrases entered do not match.")
+		}
+		if len(passphrase) == 0 {
+			cbcli_utils.ShowErrorAndExit("A passphrase is required to encrypt the archive.")
+		}
+		if archive, err = newPassphraseArchive(data, passphrase); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	if err = archive.write(archiveFile); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("Configuration exported to '%s'.", archiveFile)
+	fmt.Println()
+}
+
+func getKeyFilePassphrase() string {
+	return cbcli_utils.GetPasswordInput(
+		"key-file-passphrase",
+		"Enter the key file passphrase : ",
+	)
+}
+
+func init() {
+	flags := exportCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&exportFlags.keyFile, "key", "k", "", 
+		"PEM file with the RSA public key to encrypt the archive with")
+}
@@ -0,0 +1,418 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/mevansam/termtables"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+const (
+	conflictFail      = "fail"
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+)
+
+var importFlags = struct {
+	keyFile    string
+	dryRun     bool
+	onConflict string
+	policies   []string
+}{}
+
+var importCommand = &cobra.Command{
+	Use: "import [archive file]",
+
+	Short: "Import the configuration from an encrypted archive.",
+	Long: `
+Restores the cloud provider templates, recipe defaults and targets
+from an archive created with 'cb config export'. The cloud provider
+templates and recipe defaults in the archive replace the current ones.
+Targets in the archive are merged with the current targets. If a
+target exists both locally and in the archive and the two differ, the
+conflict is resolved using the '--on-conflict' policy, which can be
+overridden for individual targets using the '--policy' option.
+
+  fail      - abort the import without making any changes (default)
+  skip      - keep the local target
+  overwrite - replace the local target with the one in the archive
+
+A target's schedule, idle policy and pinned SSH host keys are imported
+along with the target. They are left unchanged for local targets that
+are kept.
+
+Use the '--dry-run' option to view the changes the import would make
+to the cloud provider templates, recipe defaults and targets without
+applying them.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ImportConfig(args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// change to a target resulting from an import
+type targetChange struct {
+	key    string
+	change string
+	policy string
+
+	localTarget *target.Target
+}
+
+func ImportConfig(archiveFile string) {
+
+	var (
+		err error
+
+		archive    *configArchive
+		privateKey *rsa.PrivateKey
+		data       []byte
+		payload    *archivePayload
+
+		current bytes.Buffer
+	)
+
+	policies := parseConflictPolicies()
+
+	if archive, err = readArchive(archiveFile); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if archive.Encryption == encryptionRSA {
+		if len(importFlags.keyFile) == 0 {
+			cbcli_utils.ShowErrorAndExit(
+				"The archive is encrypted with an RSA key. Provide the private key file to decrypt it with using the '--key' option.",
+			)
+		}
+		if privateKey, err = readRSAPrivateKey(importFlags.keyFile, getKeyFilePassphrase); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		if data, err = archive.decryptWithRSAKey(privateKey); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+
+	} else {
+		fmt.Println()
+		if data, err = archive.decryptWithPassphrase(
+			cbcli_utils.GetPasswordInput(
+				"archive-passphrase",
+				"Enter the passphrase the archive was encrypted with : ",
+			),
+		); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	if payload, err = archive.payload(data); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	context := cbcli_config.Config.TargetContext()
+
+	// snapshot the current configuration so it can be
+	// restored if the import is a dry-run or is aborted
+	localSettings := snapshotSettings()
+	localTargets := make(map[string]*target.Target)
+	for _, t := range context.TargetSet().GetTargets() {
+		localTargets[t.Key()] = t
+	}
+	if err = context.Save(&current); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	restore := func() {
+		if err := context.Reset(); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		if err := context.Load(bytes.NewReader(current.Bytes())); err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Failed to restore the current configuration: %s", err.Error()),
+			)
+		}
+	}
+
+	if err = context.Reset(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = context.Load(bytes.NewReader(payload.Config)); err != nil {
+		restore()
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Failed to load the configuration in the archive: %s", err.Error()),
+		)
+	}
+
+	showSettingsChanges(localSettings, snapshotSettings())
+	changes := diffTargets(localTargets, context.TargetSet().GetTargets(), policies)
+	showTargetChanges(changes)
+
+	failed := false
+	for _, c := range changes {
+		if c.policy == conflictFail {
+			failed = true
+		}
+	}
+	if failed {
+		restore()
+		cbcli_utils.ShowErrorAndExit(
+			"The archive has targets that conflict with local targets. Use the '--on-conflict' " +
+			"or '--policy' options to specify how the conflicts should be resolved.",
+		)
+	}
+	if importFlags.dryRun {
+		restore()
+		cbcli_utils.ShowNoteMessage("Dry-run only. No changes were made to the configuration.")
+		fmt.Println()
+		return
+	}
+
+	// re-add the local targets that should be kept. the
+	// settings of targets taken from the archive, such as
+	// schedules and pinned host keys, replace the local
+	// settings of those targets.
+	targetSettings := make(map[string]*cbcli_config.TargetSettings)
+	for _, c := range changes {
+		if c.localTarget != nil {
+			context.SaveTarget(c.key, c.localTarget)
+		} else if c.change != "unchanged" {
+			if settings, exists := payload.TargetSettings[c.key]; exists {
+				targetSettings[c.key] = settings
+			} else {
+				targetSettings[c.key] = &cbcli_config.TargetSettings{}
+			}
+		}
+	}
+	if err = cbcli_config.ImportTargetSettings(targetSettings); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Failed to import the target settings: %s", err.Error()),
+		)
+	}
+	cbcli_utils.ShowInfoMessage("Configuration imported from '%s'.", archiveFile)
+	fmt.Println()
+}
+
+func diffTargets(
+	localTargets map[string]*target.Target,
+	archiveTargets []*target.Target,
+	policies map[string]string,
+) []*targetChange {
+
+	changes := []*targetChange{}
+	inArchive := make(map[string]bool)
+
+	for _, t := range archiveTargets {
+		key := t.Key()
+		inArchive[key] = true
+
+		localTarget, exists := localTargets[key]
+		if !exists {
+			changes = append(changes, &targetChange{key: key, change: "add"})
+			continue
+		}
+		if targetsEqual(localTarget, t) {
+			changes = append(changes, &targetChange{key: key, change: "unchanged"})
+			continue
+		}
+
+		c := &targetChange{key: key, change: "conflict", policy: policies[key]}
+		if len(c.policy) == 0 {
+			c.policy = importFlags.onConflict
+		}
+		if c.policy == conflictSkip {
+			c.localTarget = localTarget
+		}
+		changes = append(changes, c)
+	}
+	for key, t := range localTargets {
+		if !inArchive[key] {
+			changes = append(changes, &targetChange{key: key, change: "local only", localTarget: t})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].key < changes[j].key
+	})
+	return changes
+}
+
+func targetsEqual(t1, t2 *target.Target) bool {
+
+	var (
+		err error
+
+		d1, d2 []byte
+	)
+
+	if d1, err = json.Marshal(t1); err != nil {
+		return false
+	}
+	if d2, err = json.Marshal(t2); err != nil {
+		return false
+	}
+	return bytes.Equal(d1, d2)
+}
+
+// returns the serialized cloud provider templates and
+// recipe defaults of the current configuration keyed
+// by a description of each
+func snapshotSettings() map[string][]byte {
+
+	context := cbcli_config.Config.TargetContext()
+	settings := make(map[string][]byte)
+
+	for _, cp := range context.CloudProviderTemplates() {
+		if provider, err := context.GetCloudProvider(cp.Name()); err == nil && provider != nil {
+			if data, err := json.Marshal(provider); err == nil {
+				settings[fmt.Sprintf("cloud provider '%s'", cp.Name())] = data
+			}
+		}
+	}
+	for _, r := range context.Cookbook().RecipeList() {
+		for _, c := range r.IaaSList {
+			if recipe, err := context.GetCookbookRecipe(r.RecipeKey, c.Name()); err == nil && recipe != nil {
+				if data, err := json.Marshal(recipe); err == nil {
+					settings[fmt.Sprintf("recipe '%s' for '%s'", r.RecipeKey, c.Name())] = data
+				}
+			}
+		}
+	}
+	return settings
+}
+
+func showSettingsChanges(localSettings, archiveSettings map[string][]byte) {
+
+	names := []string{}
+	for name, data := range archiveSettings {
+		if !bytes.Equal(data, localSettings[name]) {
+			names = append(names, name)
+		}
+	}
+	for name := range localSettings {
+		if _, exists := archiveSettings[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	if len(names) == 0 {
+		cbcli_utils.ShowInfoMessage("The cloud provider templates and recipe defaults in the archive are the same as the current ones.")
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.AddHeaders(
+		color.OpBold.Render("Configuration"),
+		color.OpBold.Render("Result"),
+	)
+	for _, name := range names {
+		switch _, exists := localSettings[name]; {
+		case !exists:
+			table.AddRow(name, color.Green.Render("added"))
+		case archiveSettings[name] == nil:
+			table.AddRow(name, color.Yellow.Render("removed"))
+		default:
+			table.AddRow(name, color.Yellow.Render("replaced"))
+		}
+	}
+	fmt.Print("The import will replace the following cloud provider templates and recipe defaults.\n\n")
+	fmt.Println(table.Render())
+}
+
+func showTargetChanges(changes []*targetChange) {
+
+	fmt.Println()
+	if len(changes) == 0 {
+		cbcli_utils.ShowInfoMessage("No targets found locally or in the archive...")
+		fmt.Println()
+		return
+	}
+
+	table := termtables.CreateTable()
+	table.AddHeaders(
+		color.OpBold.Render("Target"),
+		color.OpBold.Render("Change"),
+		color.OpBold.Render("Result"),
+	)
+	for _, c := range changes {
+		var result string
+
+		switch c.change {
+		case "add":
+			result = color.Green.Render("added")
+		case "unchanged", "local only":
+			result = "kept"
+		default:
+			switch c.policy {
+			case conflictSkip:
+				result = color.Yellow.Render("kept local")
+			case conflictOverwrite:
+				result = color.Yellow.Render("overwritten")
+			default:
+				result = color.Red.Render("conflict")
+			}
+		}
+		table.AddRow(c.key, c.change, result)
+	}
+	fmt.Print("The import will make the following changes to the configured targets.\n\n")
+	fmt.Println(table.Render())
+}
+
+// parses the per-target conflict policies
+// given as '<target key>=<policy>'
+func parseConflictPolicies() map[string]string {
+
+	policies := make(map[string]string)
+
+	validatePolicy := func(policy string) {
+		switch policy {
+		case conflictFail, conflictSkip, conflictOverwrite:
+		default:
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Invalid conflict policy '%s'. The policy must be one of 'fail', 'skip' or 'overwrite'.",
+					policy,
+				),
+			)
+		}
+	}
+
+	validatePolicy(importFlags.onConflict)
+	for _, p := range importFlags.policies {
+		i := strings.LastIndex(p, "=")
+		if i == -1 {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Invalid target policy '%s'. The format must be <target key>=<policy>.", p),
+			)
+		}
+		validatePolicy(p[i+1:])
+		policies[p[:i]] = p[i+1:]
+	}
+	return policies
+}
+
+func init() {
+	flags := importCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&importFlags.keyFile, "key", "k", "", 
+		"PEM file with the RSA private key to decrypt the archive with")
+	flags.BoolVarP(&importFlags.dryRun, "dry-run", "d", false, 
+		"show the changes the import would make without applying them")
+	flags.StringVarP(&importFlags.onConflict, "on-conflict", "c", conflictFail, 
+		"how to resolve targets that differ locally and in the archive\n(fail, skip or overwrite)")
+	flags.StringArrayVarP(&importFlags.policies, "policy", "p", []string{}, 
+		"conflict policy for a specific target (format <target key>=<policy>)")
+}
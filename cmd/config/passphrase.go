@@ -0,0 +1,216 @@
+package config
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/config"
+	"github.com/appbricks/cloud-builder/cookbook"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var passphraseCommand = &cobra.Command{
+	Use: "passphrase",
+
+	Short: "Change the passphrase that encrypts the configuration.",
+	Long: `
+Changes the passphrase that encrypts the configuration file including
+the cached state of all targets. The current passphrase is verified
+before the configuration is re-encrypted with the new passphrase. The
+configuration file is backed up before it is re-encrypted and it is
+restored if the configuration cannot be saved or unlocked with the
+new passphrase.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ChangePassphrase()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func ChangePassphrase() {
+
+	var (
+		err error
+
+		configData []byte
+	)
+
+	if !cbcli_config.Config.Initialized() || len(cbcli_config.Passphrase) == 0 {
+		cbcli_utils.ShowErrorAndExit("The configuration has not been secured with a passphrase. Please run 'cb init'.")
+	}
+	oldPassphrase := cbcli_config.Passphrase
+
+	fmt.Println()
+	if subtle.ConstantTimeCompare(
+		[]byte(cbcli_utils.GetPasswordInput("config-passphrase", "Enter the current passphrase : ")),
+		[]byte(oldPassphrase),
+	) != 1 {
+		cbcli_utils.ShowErrorAndExit("The current passphrase is incorrect.")
+	}
+	newPassphrase := cbcli_utils.GetPasswordInput(
+		"new-config-passphrase",
+		"Enter the new passphrase : ",
+	)
+	if !cbcli_utils.NonInteractive &&
+		newPassphrase != cbcli_utils.GetPasswordInput("new-config-passphrase", "Confirm the new passphrase : ") {
+		cbcli_utils.ShowErrorAndExit("The passphrases entered do not match.")
+	}
+	if len(newPassphrase) == 0 {
+		cbcli_utils.ShowErrorAndExit("The new passphrase cannot be empty.")
+	}
+	if newPassphrase == oldPassphrase {
+		cbcli_utils.ShowErrorAndExit("The new passphrase is the same as the current passphrase.")
+	}
+
+	// back up the configuration encrypted with the current
+	// passphrase. the backup is left in place should the
+	// CLI be interrupted while the configuration is saved.
+	configFile := cbcli_config.ConfigFile
+	backupFile := configFile + ".bak"
+	if configData, err = os.ReadFile(configFile); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = writeFileAtomic(backupFile, configData); err != nil {
+		logger.DebugMessage("ChangePassphrase(): Failed to back up the configuration: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("Unable to back up the configuration file.")
+	}
+
+	systemPassphrase := config.SystemPassphrase
+	rollback := func(cause error) {
+		logger.DebugMessage("ChangePassphrase(): Rolling back passphrase change: %s", cause.Error())
+
+		config.SystemPassphrase = systemPassphrase
+		cbcli_config.Config.SetPassphrase(oldPassphrase)
+		if err := writeFileAtomic(configFile, configData); err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Failed to restore the configuration: %s. The configuration encrypted with the current passphrase has been saved to '%s'",
+					err.Error(), backupFile,
+				),
+			)
+		}
+		os.Remove(backupFile)
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Failed to change the passphrase: %s. The configuration has not been changed", cause.Error()),
+		)
+	}
+
+	cbcli_config.Config.SetPassphrase(newPassphrase)
+	if err = cbcli_config.Config.Save(); err != nil {
+		rollback(err)
+	}
+	if err = verifyPassphrase(configFile, newPassphrase); err != nil {
+		rollback(err)
+	}
+	// the target settings are encrypted with a
+	// key derived from the config passphrase
+	if err = cbcli_config.ReencryptTargetSettings(oldPassphrase, newPassphrase); err != nil {
+		rollback(err)
+	}
+	os.Remove(backupFile)
+
+	// ensure the new passphrase is handed
+	// to the passphrase agent if running
+	cbcli_config.Passphrase = newPassphrase
+	if systemPassphrase != nil {
+		config.SystemPassphrase = func() string {
+			return newPassphrase
+		}
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("The configuration passphrase has been changed.")
+	if len(os.Getenv("CBS_SYSTEM_PASSPHRASE")) > 0 {
+		cbcli_utils.ShowWarningMessage(
+			"\nThe CBS_SYSTEM_PASSPHRASE environment variable needs to be updated with the new passphrase.",
+		)
+	}
+	fmt.Println()
+}
+
+// verifies the saved configuration
+// can be unlocked with the passphrase
+func verifyPassphrase(configFile, passphrase string) error {
+
+	var (
+		err error
+
+		cbCookbook *cookbook.Cookbook
+		saved      config.Config
+	)
+
+	passphraseFn := func() string {
+		return passphrase
+	}
+	// the system passphrase takes precedence so it
+	// needs to be set to the passphrase to verify
+	systemPassphrase := config.SystemPassphrase
+	if systemPassphrase != nil {
+		config.SystemPassphrase = passphraseFn
+		defer func() {
+			config.SystemPassphrase = systemPassphrase
+		}()
+	}
+
+	if cbCookbook, err = cbcli_cookbook.NewCookbook(); err != nil {
+		return err
+	}
+	if saved, err = config.InitFileConfig(
+		configFile, cbCookbook, passphraseFn,
+		func(key string, configData []byte, asOf int64) (int64, error) {
+			return asOf, nil
+		},
+	); err != nil {
+		return err
+	}
+	if err = saved.Load(); err != nil {
+		return fmt.Errorf("the saved configuration could not be unlocked with the new passphrase")
+	}
+	return nil
+}
+
+// writes a file by writing to a temporary file that
+// is renamed so the file is replaced atomically
+func writeFileAtomic(path string, data []byte) error {
+
+	var (
+		err error
+
+		tmpFile *os.File
+	)
+
+	if tmpFile, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"); err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -84,6 +84,7 @@ func AddUser(deviceName string, userName string) {
 		}		
 		fmt.Println()
 		if response = cbcli_utils.GetUserInputFromList(
+			"select-user",
 			"Enter # user to add or (q)uit: ",
 			"", optionList, false); response == "q" {
 			fmt.Println()
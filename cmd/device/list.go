@@ -30,10 +30,36 @@ Lists all the managed devices created for the current primary device.
 	},
 }
 
+// structured output schema for the device list. the
+// schema is documented in doc/output.md and fields
+// should only be added to it.
+type devicesOutput struct {
+	PrimaryDevice  deviceOutput   `json:"primaryDevice" yaml:"primaryDevice"`
+	GuestUsers     []userOutput   `json:"guestUsers" yaml:"guestUsers"`
+	ManagedDevices []deviceOutput `json:"managedDevices" yaml:"managedDevices"`
+}
+
+type deviceOutput struct {
+	Name  string       `json:"name" yaml:"name"`
+	Type  string       `json:"type" yaml:"type"`
+	Users []userOutput `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+type userOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	FullName string `json:"fullName,omitempty" yaml:"fullName,omitempty"`
+	Active   bool   `json:"active" yaml:"active"`
+}
+
 func ListDevices() {
 
 	deviceContext := cbcli_config.Config.DeviceContext()
 
+	if !cbcli_utils.IsTableOutput() {
+		renderDevicesOutput()
+		return
+	}
+
 	fmt.Printf(
 		"\n%s\n==============\n\n", 
 		color.OpBold.Render("Primary Device"),
@@ -143,6 +169,42 @@ func ListDevices() {
 	fmt.Println()
 }
 
+func renderDevicesOutput() {
+
+	deviceContext := cbcli_config.Config.DeviceContext()
+
+	output := devicesOutput{
+		PrimaryDevice: deviceOutput{
+			Name: deviceContext.GetDevice().Name,
+			Type: deviceContext.GetDevice().Type,
+		},
+		GuestUsers:     []userOutput{},
+		ManagedDevices: []deviceOutput{},
+	}
+	for _, u := range deviceContext.GetGuestUsers() {
+		output.GuestUsers = append(output.GuestUsers, userOutput{
+			Name:     u.Name,
+			FullName: utils.FormatFullName(u.FirstName, u.MiddleName, u.FamilyName),
+			Active:   u.Active,
+		})
+	}
+	for _, device := range deviceContext.GetManagedDevices() {
+		managedDevice := deviceOutput{
+			Name: device.Name,
+			Type: device.Type,
+		}
+		for _, u := range device.DeviceUsers {
+			managedDevice.Users = append(managedDevice.Users, userOutput{
+				Name:     u.Name,
+				FullName: utils.FormatFullName(u.FirstName, u.MiddleName, u.FamilyName),
+				Active:   u.Active,
+			})
+		}
+		output.ManagedDevices = append(output.ManagedDevices, managedDevice)
+	}
+	cbcli_utils.RenderOutput(output)
+}
+
 func init() {
 	flags := listCommand.Flags()
 	flags.SortFlags = false
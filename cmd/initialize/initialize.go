@@ -22,6 +22,7 @@ import (
 	"github.com/mevansam/goutils/crypto"
 	"github.com/mevansam/goutils/logger"
 
+	cbcli_agent "github.com/appbricks/cloud-builder-cli/agent"
 	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
 	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
 	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
@@ -94,6 +95,10 @@ func initialize() {
 	config := cbcli_config.Config
 	deviceContext := config.DeviceContext()
 
+	// initialization requires the user to login and
+	// provide key and passphrase input interactively
+	cbcli_utils.AssertInteractive("init", "cb init must be run interactively")
+
 	line := liner.NewLiner()
 	line.SetCtrlCAborts(true)
 	defer func() {
@@ -119,7 +124,7 @@ func initialize() {
 	if deviceOwner, isSet := deviceContext.GetOwnerUserName(); isSet {
 		fmt.Println()
 
-		resetConfig = cbcli_utils.GetYesNoUserInput("Do you wish to reset the primary user : ", false)
+		resetConfig = cbcli_utils.GetYesNoUserInput("reset-primary-user", "Do you wish to reset the primary user : ", false)
 		if resetConfig {
 			// confirm device owner by forcing user to re-login
 			fmt.Println()
@@ -127,8 +132,8 @@ func initialize() {
 				"Resetting the primary user will also reset any saved configurations. If the current " +
 				"primary user has deployed cloud spaces and applications their configurations will be " +
 				"lost and may not be able to be recovered. Before proceding please ensure that you have " +
-				"exported the current configuration, in case you need to recover deployments associated " +
-				"with the current configuration.",
+				"exported the current configuration using 'cb config export', in case you need to recover " +
+				"deployments associated with the current configuration.",
 			)
 
 			if awsAuth, err = cbcli_auth.GetAuthenticatedToken(
@@ -204,6 +209,7 @@ func initialize() {
 			)
 			fmt.Println()
 			importKey = cbcli_utils.GetYesNoUserInput(
+				"import-private-key",
 				fmt.Sprintf("Do you wish to import a private key for user '%s' : ", userName),
 				false,
 			)
@@ -371,7 +377,7 @@ func initialize() {
 
 	resetPassphrase = true
 	if config.Initialized() {		
-		resetPassphrase = cbcli_utils.GetYesNoUserInput("Do you wish to reset the passphrase : ", false)
+		resetPassphrase = cbcli_utils.GetYesNoUserInput("reset-passphrase", "Do you wish to reset the passphrase : ", false)
 	}
 	if resetPassphrase {
 		fmt.Println()
@@ -386,6 +392,7 @@ func initialize() {
 			os.Exit(1)
 		}
 		config.SetPassphrase(passphrase)
+		cbcli_config.Passphrase = passphrase
 	}
 
 	fmt.Println()
@@ -423,6 +430,9 @@ func initialize() {
 	} else {
 		config.SetKeyTimeout(0)
 	}
+	// discard the passphrase held by the passphrase agent
+	// so it is retained again with the new unlock timeout
+	_ = cbcli_agent.Lock()
 
 	// register device with MyCS account service with current 
 	// logged in user as owner. do this last to ensure all 
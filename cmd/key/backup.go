@@ -0,0 +1,150 @@
+package key
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gookit/color"
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var backupFlags = struct {
+	shares    int
+	threshold int
+	format    string
+	outDir    string
+}{}
+
+var backupCommand = &cobra.Command{
+	Use: "backup",
+
+	Short: "Back up the device owner's private key as a set of shares.",
+	Long: `
+Splits the device owner's private key into a number of shares using
+Shamir's secret sharing. Any threshold number of shares can be combined
+using 'cb key recover' to rebuild the key, whereas fewer shares reveal
+nothing about the key. This allows custody of the key to be split
+between several people or locations. Each share can be output as text
+or as a QR code which can be printed for a paper backup.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		BackupKey()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func BackupKey() {
+
+	var (
+		err error
+
+		secret []byte
+		shares map[byte][]byte
+	)
+
+	if backupFlags.format != "text" && backupFlags.format != "qr" {
+		cbcli_utils.ShowErrorAndExit("The share format must be one of 'text' or 'qr'.")
+	}
+
+	owner := cbcli_config.Config.DeviceContext().GetOwner()
+	if len(owner.RSAPrivateKey) == 0 {
+		cbcli_utils.ShowErrorAndExit("The device owner's private key has not been imported to this device.")
+	}
+	if secret, err = keySecretFromPEM(owner.RSAPrivateKey); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if shares, err = splitSecret(secret, backupFlags.shares, backupFlags.threshold); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	fingerprint := secretFingerprint(secret)
+
+	indexes := make([]int, 0, len(shares))
+	for x := range shares {
+		indexes = append(indexes, int(x))
+	}
+	sort.Ints(indexes)
+
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage(
+		"The private key of user '%s' has been split into %d shares. Any %d of these shares can be " +
+		"used to recover the key. Store each share separately in a secure location.",
+		owner.Name, backupFlags.shares, backupFlags.threshold,
+	)
+	fmt.Println()
+
+	for _, x := range indexes {
+		share := &keyShare{
+			index:       byte(x),
+			threshold:   backupFlags.threshold,
+			fingerprint: fingerprint,
+			data:        shares[byte(x)],
+		}
+		if len(backupFlags.outDir) > 0 {
+			shareFile := writeShare(share, owner.Name)
+			fmt.Printf("Share %d of %d saved to: %s\n", x, backupFlags.shares, shareFile)
+			continue
+		}
+
+		fmt.Println(color.OpBold.Render(fmt.Sprintf("Share %d of %d", x, backupFlags.shares)))
+		fmt.Println()
+		if backupFlags.format == "qr" {
+			qr, err := qrcode.New(share.String(), qrcode.Low)
+			if err != nil {
+				cbcli_utils.ShowErrorAndExit(err.Error())
+			}
+			fmt.Println(qr.ToSmallString(false))
+		}
+		fmt.Println(share.String())
+		fmt.Println()
+	}
+	fmt.Println()
+}
+
+// writes the share to a file in the output directory
+// in the selected format and returns the file's path
+func writeShare(share *keyShare, userName string) string {
+
+	var (
+		err error
+
+		shareFile string
+	)
+
+	name := fmt.Sprintf("%s-key-share-%d", userName, share.index)
+	if backupFlags.format == "qr" {
+		shareFile = filepath.Join(backupFlags.outDir, name+".png")
+		err = qrcode.WriteFile(share.String(), qrcode.Low, 1024, shareFile)
+	} else {
+		shareFile = filepath.Join(backupFlags.outDir, name+".txt")
+		err = os.WriteFile(shareFile, []byte(share.String()+"\n"), 0600)
+	}
+	if err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	return shareFile
+}
+
+func init() {
+	flags := backupCommand.Flags()
+	flags.SortFlags = false
+	flags.IntVarP(&backupFlags.shares, "shares", "n", 5, 
+		"number of shares to split the key into")
+	flags.IntVarP(&backupFlags.threshold, "threshold", "k", 3, 
+		"number of shares required to recover the key")
+	flags.StringVarP(&backupFlags.format, "format", "f", "text", 
+		"output format of the shares (text or qr)")
+	flags.StringVarP(&backupFlags.outDir, "out-dir", "d", "", 
+		"directory to save each share to as a separate file\n(.txt for text and .png for qr)")
+}
@@ -0,0 +1,23 @@
+package key
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var KeyCommands = &cobra.Command{
+	Use: "key",
+
+	Short: "Manage the device owner's private key.",
+	Long: `
+The device owner's RSA key-pair secures all data associated with the
+owner including the owner's configuration which is encrypted with the
+owner's public key before it is uploaded. The sub-commands below allow
+the owner to rotate and back up the private key.
+`,
+}
+
+func init() {
+	KeyCommands.AddCommand(rotateCommand)
+	KeyCommands.AddCommand(backupCommand)
+	KeyCommands.AddCommand(recoverCommand)
+}
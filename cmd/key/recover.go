@@ -0,0 +1,231 @@
+package key
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/mycloudspace-client/api"
+	"github.com/appbricks/mycloudspace-client/mycscloud"
+	"github.com/mevansam/goutils/crypto"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var recoverFlags = struct {
+	keyFile string
+}{}
+
+var recoverCommand = &cobra.Command{
+	Use: "recover [share file...]",
+
+	Short: "Recover the device owner's private key from a set of shares.",
+	Long: `
+Rebuilds the device owner's private key from shares created with 'cb
+key backup' and imports it to this device. Provide the files the
+shares were saved to as arguments or, if no files are provided, enter
+each share when prompted. Shares saved as QR codes can be entered by
+scanning the code and entering the text it contains. The recovered
+key is validated against the owner's known public key before it is
+imported and can optionally be saved to a passphrase protected file.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		RecoverKey(args)
+	},
+}
+
+func RecoverKey(shareFiles []string) {
+
+	var (
+		err error
+
+		awsAuth *cbcli_auth.AWSCognitoJWT
+
+		shares []*keyShare
+		share  *keyShare
+		data   []byte
+
+		secret []byte
+		keyPEM string
+		key    *crypto.RSAKey
+	)
+
+	config := cbcli_config.Config
+	deviceContext := config.DeviceContext()
+
+	if !config.Initialized() {
+		cbcli_utils.ShowErrorAndExit(
+			"The Cloud Builder client has not been initialized. Run 'cb init' to initialize it.",
+		)
+	}
+	// the key is recovered before the logged in user is
+	// authorized as authorizing the owner requires the
+	// owner's private key to have been imported
+	if awsAuth, err = cbcli_auth.GetAuthenticatedToken(config, false,
+		"Login as the device owner whose private key you want to recover.",
+	); err != nil {
+		logger.ErrorMessage("RecoverKey(): Authentication returned error: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("My Cloud Space user authentication failed.")
+	}
+	if ownerUserID, isOwnerSet := deviceContext.GetOwnerUserID(); !isOwnerSet || ownerUserID != awsAuth.UserID() {
+		cbcli_utils.ShowErrorAndExit("Only the device owner can recover the owner's private key.")
+	}
+
+	if len(shareFiles) > 0 {
+		for _, shareFile := range shareFiles {
+			if data, err = os.ReadFile(shareFile); err != nil {
+				cbcli_utils.ShowErrorAndExit(err.Error())
+			}
+			if share, err = parseKeyShare(string(data)); err != nil {
+				cbcli_utils.ShowErrorAndExit(fmt.Sprintf("File '%s' is %s.", shareFile, err.Error()))
+			}
+			shares = append(shares, share)
+		}
+
+	} else {
+		cbcli_utils.AssertInteractive("key-share", "enter the key shares")
+
+		fmt.Println()
+		for threshold := 2; len(shares) < threshold; {
+			if share, err = parseKeyShare(
+				cbcli_utils.GetUserInput(
+					"key-share",
+					fmt.Sprintf("Enter share %d of %d : ", len(shares)+1, threshold),
+				),
+			); err != nil {
+				cbcli_utils.ShowWarningMessage("The value entered is %s. Please try again.", err.Error())
+				continue
+			}
+			if err = checkNewKeyShare(shares, share); err != nil {
+				cbcli_utils.ShowWarningMessage("The share entered %s. Please enter another share.", err.Error())
+				continue
+			}
+			threshold = share.threshold
+			shares = append(shares, share)
+		}
+	}
+
+	if secret, err = combineKeyShares(shares); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if keyPEM, err = keyPEMFromSecret(secret); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if key, err = crypto.NewRSAKeyFromPEM(keyPEM, nil); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("The recovered private key is not valid: %s", err.Error()),
+		)
+	}
+
+	// validate known public key with the recovered private
+	// key in the same way as an imported private key
+	owner := deviceContext.GetOwner()
+	userAPI := mycscloud.NewUserAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+	if _, err = userAPI.GetUser(owner); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = owner.SetKey(key); err != nil {
+		cbcli_utils.ShowErrorAndExit("Failed to validate recovered private key with user's known public key.")
+	}
+	if err = cbcli_auth.AuthorizeDeviceAndUser(config); err != nil {
+		logger.ErrorMessage("RecoverKey(): Authorizing logged in user on this device returned error: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("My Cloud Space device and user authorization failed.")
+	}
+
+	if len(recoverFlags.keyFile) > 0 {
+		saveRecoveredKey(key, recoverFlags.keyFile)
+	}
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("The private key of user '%s' has been recovered and imported.", owner.Name)
+	fmt.Println()
+}
+
+// returns an error if the given share does not belong to
+// the same key backup as the shares already entered or
+// has already been entered
+func checkNewKeyShare(shares []*keyShare, share *keyShare) error {
+	for _, s := range shares {
+		if s.fingerprint != share.fingerprint || s.threshold != share.threshold {
+			return fmt.Errorf("does not belong to the same key backup as the shares already entered")
+		}
+		if s.index == share.index {
+			return fmt.Errorf("has already been entered")
+		}
+	}
+	return nil
+}
+
+// validates that the shares belong to the same key
+// and combines them to recover the key's secret
+func combineKeyShares(shares []*keyShare) ([]byte, error) {
+
+	var (
+		err error
+
+		secret []byte
+	)
+
+	fingerprint := shares[0].fingerprint
+	threshold := shares[0].threshold
+
+	shareData := make(map[byte][]byte)
+	for _, s := range shares {
+		if s.fingerprint != fingerprint || s.threshold != threshold {
+			return nil, fmt.Errorf("the shares provided do not belong to the same key backup")
+		}
+		if data, exists := shareData[s.index]; exists && !bytes.Equal(data, s.data) {
+			return nil, fmt.Errorf("share %d was provided more than once with different data", s.index)
+		}
+		shareData[s.index] = s.data
+	}
+	if len(shareData) < threshold {
+		return nil, fmt.Errorf(
+			"%d unique shares were provided but %d are required to recover the key",
+			len(shareData), threshold,
+		)
+	}
+	if secret, err = combineShares(shareData); err != nil {
+		return nil, err
+	}
+	if secretFingerprint(secret) != fingerprint {
+		return nil, fmt.Errorf("the key recovered from the shares does not match the key that was backed up")
+	}
+	return secret, nil
+}
+
+func saveRecoveredKey(key *crypto.RSAKey, keyFile string) {
+
+	var (
+		err error
+
+		keyPEM string
+	)
+
+	fmt.Println()
+	passphrase := cbcli_utils.GetPasswordInput("key-file-passphrase", "Enter the key file passphrase : ")
+	if !cbcli_utils.NonInteractive &&
+		passphrase != cbcli_utils.GetPasswordInput("key-file-passphrase", "Verify the key file passphrase : ") {
+		cbcli_utils.ShowErrorAndExit("Passphrases do not match.")
+	}
+	if keyPEM, err = key.GetEncryptedPrivateKeyPEM([]byte(passphrase)); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = os.WriteFile(keyFile, []byte(keyPEM), 0600); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage("The recovered private key has been saved to '%s'.", keyFile)
+}
+
+func init() {
+	flags := recoverCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&recoverFlags.keyFile, "key-file", "f", "", 
+		"save the recovered key to a passphrase protected PEM file")
+}
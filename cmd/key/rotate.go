@@ -0,0 +1,224 @@
+package key
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/userspace"
+	"github.com/appbricks/mycloudspace-client/api"
+	"github.com/appbricks/mycloudspace-client/mycscloud"
+	"github.com/mevansam/goutils/crypto"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var rotateFlags = struct {
+	keyDir string
+}{}
+
+var rotateCommand = &cobra.Command{
+	Use: "rotate",
+
+	Short: "Rotate the device owner's private key.",
+	Long: `
+Generates a new RSA key-pair for the device owner and re-encrypts the
+owner's configuration with it. The new private key is saved to a
+passphrase protected PEM file and must be verified by re-entering the
+passphrase before the new public key is published. The previous key is
+saved alongside the new key, encrypted with the same passphrase, so
+that the configuration encrypted with it remains recoverable if the
+rotation does not complete. Once the rotation has been confirmed you
+should import the new key on all other devices of the owner and
+securely delete the previous key file.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		RotateKey()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func RotateKey() {
+
+	var (
+		err error
+
+		fi os.FileInfo
+
+		oldKey,
+		newKey,
+		verifyKey *crypto.RSAKey
+
+		oldKeyPEM,
+		newKeyPEM,
+		keyDir,
+		passphrase string
+
+		configTimestamp int64
+	)
+
+	config := cbcli_config.Config
+	owner := config.DeviceContext().GetOwner()
+
+	if len(owner.RSAPrivateKey) == 0 {
+		cbcli_utils.ShowErrorAndExit("The device owner's private key has not been imported to this device.")
+	}
+	if oldKey, err = crypto.NewRSAKeyFromPEM(owner.RSAPrivateKey, nil); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to load the current private key: %s", err.Error()),
+		)
+	}
+
+	// snapshot the configuration which will
+	// be re-encrypted with the new key
+	currentConfig := new(bytes.Buffer)
+	if err = config.TargetContext().Save(currentConfig); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	keyDir = rotateFlags.keyDir
+	if len(keyDir) == 0 {
+		fmt.Println()
+		keyDir = strings.Trim(
+			cbcli_utils.GetUserInput(
+				"key-file-directory",
+				"Path to save key file (you can drag/drop from a finder/explorer window to the terminal) : ",
+			),
+			" '\"",
+		)
+	}
+	if fi, err = os.Stat(keyDir); err != nil || !fi.IsDir() {
+		cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Path '%s' is not a directory.", keyDir))
+	}
+
+	passphrase = cbcli_utils.GetPasswordInput("key-file-passphrase", "Enter the key file passphrase : ")
+	if !cbcli_utils.NonInteractive &&
+		passphrase != cbcli_utils.GetPasswordInput("key-file-passphrase", "Verify the key file passphrase : ") {
+		cbcli_utils.ShowErrorAndExit("Passphrases do not match.")
+	}
+
+	// save the new key and the previous key encrypted with
+	// the key file passphrase before making any changes
+	timestamp := time.Now().Format("20060102150405")
+	newKeyFile := filepath.Join(keyDir, fmt.Sprintf("%s-key.pem", owner.Name))
+	if _, err = os.Stat(newKeyFile); err == nil {
+		newKeyFile = filepath.Join(keyDir, fmt.Sprintf("%s-key-%s.pem", owner.Name, timestamp))
+	}
+	oldKeyFile := filepath.Join(keyDir, fmt.Sprintf("%s-key-%s-previous.pem", owner.Name, timestamp))
+
+	if newKey, err = crypto.NewRSAKey(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if newKeyPEM, err = newKey.GetEncryptedPrivateKeyPEM([]byte(passphrase)); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if oldKeyPEM, err = oldKey.GetEncryptedPrivateKeyPEM([]byte(passphrase)); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = os.WriteFile(oldKeyFile, []byte(oldKeyPEM), 0600); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = os.WriteFile(newKeyFile, []byte(newKeyPEM), 0600); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage("A new RSA private key has been generated and saved to the following path:")
+	cbcli_utils.ShowNoteMessage(fmt.Sprintf("- %s", newKeyFile))
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage("The previous private key has been saved to the following path:")
+	cbcli_utils.ShowNoteMessage(fmt.Sprintf("- %s", oldKeyFile))
+	fmt.Println()
+
+	// confirm the rotation by verifying the new
+	// key file can be decrypted by the owner
+	if !cbcli_utils.GetYesNoUserInput(
+		"confirm-key-rotation",
+		"Do you wish to publish the new key and re-encrypt your configuration with it : ",
+		false,
+	) {
+		cbcli_utils.ShowWarningMessage("\nKey rotation was cancelled. No changes were made to your configuration.\n")
+		return
+	}
+	if verifyKey, err = crypto.NewRSAKeyFromFile(
+		newKeyFile,
+		[]byte(cbcli_utils.GetPasswordInput("key-file-passphrase", "Re-enter the new key file passphrase to confirm : ")),
+	); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to verify the new key file. No changes were made to your configuration: %s", err.Error()),
+		)
+	}
+	if err = owner.SetKey(verifyKey); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	userAPI := mycscloud.NewUserAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+
+	if err = userAPI.UpdateUserKey(owner); err != nil {
+		rollbackKey(userAPI, owner, oldKey, false)
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Failed to publish the new public key: %s", err.Error()),
+		)
+	}
+	if configTimestamp, err = userAPI.UpdateUserConfig(owner, currentConfig.Bytes(), config.GetConfigAsOf()); err != nil {
+		rollbackKey(userAPI, owner, oldKey, true)
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Failed to upload the configuration encrypted with the new key: %s", err.Error()),
+		)
+	}
+	config.SetConfigAsOf(configTimestamp)
+
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("The device owner's key has been rotated.")
+	fmt.Println()
+	cbcli_utils.ShowNoticeMessage(
+		"You will need to import the new private key on all other devices you own. Once done, " +
+		"securely delete the previous key file as well as any other copies of the previous key.",
+	)
+	fmt.Println()
+}
+
+// reverts the owner's key to the previous key and
+// republishes the previous public key if required
+func rollbackKey(userAPI *mycscloud.UserAPI, owner *userspace.User, oldKey *crypto.RSAKey, republish bool) {
+
+	var (
+		err error
+	)
+
+	if err = owner.SetKey(oldKey); err != nil {
+		logger.ErrorMessage("rollbackKey(): Failed to restore the previous key: %s", err.Error())
+		cbcli_utils.ShowWarningMessage(
+			"Unable to restore the previous key. Import the previous key file to recover your configuration.",
+		)
+		return
+	}
+	if republish {
+		if err = userAPI.UpdateUserKey(owner); err != nil {
+			logger.ErrorMessage("rollbackKey(): Failed to republish the previous public key: %s", err.Error())
+			cbcli_utils.ShowWarningMessage(
+				"Unable to republish the previous public key. Your configuration remains encrypted with the " +
+				"previous key. Re-run 'cb key rotate' to complete the rotation.",
+			)
+		}
+	}
+}
+
+func init() {
+	flags := rotateCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&rotateFlags.keyDir, "key-dir", "d", "", 
+		"directory to save the new private key file to")
+}
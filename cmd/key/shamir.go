@@ -0,0 +1,140 @@
+package key
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// Shamir's secret sharing over GF(2^8). each byte of the
+// secret is the constant term of a random polynomial of
+// degree threshold-1 and a share is the evaluation of all
+// the polynomials at the share's non-zero x coordinate.
+
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	// generate the exponent and log tables
+	// using 3 as the generator of GF(2^8)
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfExp[i+255] = x
+		gfLog[x] = byte(i)
+		x = gfMulNoTable(x, 3)
+	}
+}
+
+func gfMulNoTable(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		hi := a & 0x80
+		a <<= 1
+		if hi != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// splits the secret into n shares of which any threshold
+// shares can be combined to recover the secret. the
+// returned map is keyed by each share's x coordinate.
+func splitSecret(secret []byte, n, threshold int) (map[byte][]byte, error) {
+
+	var (
+		err error
+	)
+
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("the threshold must be between 2 and the number of shares, which can be at most 255")
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+
+	shares := make(map[byte][]byte, n)
+	for x := 1; x <= n; x++ {
+		shares[byte(x)] = make([]byte, len(secret))
+	}
+
+	coefficients := make([]byte, threshold)
+	for i, s := range secret {
+		coefficients[0] = s
+		if _, err = io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for x, share := range shares {
+			// evaluate the polynomial using horner's method
+			y := coefficients[threshold-1]
+			for j := threshold - 2; j >= 0; j-- {
+				y = gfMul(y, x) ^ coefficients[j]
+			}
+			share[i] = y
+		}
+	}
+	return shares, nil
+}
+
+// recovers the secret from the given shares keyed by
+// their x coordinate using lagrange interpolation at 0
+func combineShares(shares map[byte][]byte) ([]byte, error) {
+
+	var (
+		length int
+	)
+
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("at least two shares are required to recover the secret")
+	}
+	xs := make([]byte, 0, len(shares))
+	for x, share := range shares {
+		if x == 0 {
+			return nil, fmt.Errorf("invalid share index 0")
+		}
+		if length == 0 {
+			length = len(share)
+		} else if len(share) != length {
+			return nil, fmt.Errorf("the shares are not of the same length")
+		}
+		xs = append(xs, x)
+	}
+
+	secret := make([]byte, length)
+	for i := range secret {
+		var s byte
+		for _, xi := range xs {
+			// lagrange basis polynomial for xi evaluated at 0
+			basis := byte(1)
+			for _, xj := range xs {
+				if xi != xj {
+					basis = gfMul(basis, gfDiv(xj, xj^xi))
+				}
+			}
+			s ^= gfMul(shares[xi][i], basis)
+		}
+		secret[i] = s
+	}
+	return secret, nil
+}
@@ -0,0 +1,241 @@
+package key
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testSecret(t *testing.T, size int) []byte {
+	secret := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		t.Fatalf("unable to create secret: %s", err.Error())
+	}
+	return secret
+}
+
+// calls fn with every subset of the given
+// share indexes that has the given size
+func forEachSubset(indexes []byte, size int, fn func(subset []byte)) {
+	var walk func(start int, subset []byte)
+	walk = func(start int, subset []byte) {
+		if len(subset) == size {
+			fn(append([]byte{}, subset...))
+			return
+		}
+		for i := start; i < len(indexes); i++ {
+			walk(i+1, append(subset, indexes[i]))
+		}
+	}
+	walk(0, []byte{})
+}
+
+func TestGFArithmetic(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			p := gfMul(byte(a), byte(b))
+			if p != gfMulNoTable(byte(a), byte(b)) {
+				t.Fatalf("gfMul(%d, %d) does not match multiplication without tables", a, b)
+			}
+			if b != 0 && gfDiv(p, byte(b)) != byte(a) {
+				t.Fatalf("gfDiv(gfMul(%d, %d), %d) != %d", a, b, b, a)
+			}
+		}
+	}
+}
+
+func TestSplitAndCombineAllThresholdSubsets(t *testing.T) {
+
+	secret := testSecret(t, 64)
+
+	for n := 2; n <= 6; n++ {
+		for threshold := 2; threshold <= n; threshold++ {
+
+			shares, err := splitSecret(secret, n, threshold)
+			if err != nil {
+				t.Fatalf("splitSecret(n=%d, threshold=%d) failed: %s", n, threshold, err.Error())
+			}
+			if len(shares) != n {
+				t.Fatalf("expected %d shares but got %d", n, len(shares))
+			}
+			indexes := []byte{}
+			for x := range shares {
+				indexes = append(indexes, x)
+			}
+
+			// every subset with at least the
+			// threshold number of shares recovers
+			// the secret
+			for size := threshold; size <= n; size++ {
+				forEachSubset(indexes, size, func(subset []byte) {
+					subsetShares := make(map[byte][]byte)
+					for _, x := range subset {
+						subsetShares[x] = shares[x]
+					}
+					recovered, err := combineShares(subsetShares)
+					if err != nil {
+						t.Fatalf("combineShares(%v) failed: %s", subset, err.Error())
+					}
+					if !bytes.Equal(recovered, secret) {
+						t.Fatalf("shares %v of n=%d, threshold=%d did not recover the secret", subset, n, threshold)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestCombineFewerThanThresholdShares(t *testing.T) {
+
+	secret := testSecret(t, 64)
+
+	shares, err := splitSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("splitSecret failed: %s", err.Error())
+	}
+	indexes := []byte{}
+	for x := range shares {
+		indexes = append(indexes, x)
+	}
+	forEachSubset(indexes, 2, func(subset []byte) {
+		subsetShares := map[byte][]byte{
+			subset[0]: shares[subset[0]],
+			subset[1]: shares[subset[1]],
+		}
+		recovered, err := combineShares(subsetShares)
+		if err != nil {
+			t.Fatalf("combineShares(%v) failed: %s", subset, err.Error())
+		}
+		if bytes.Equal(recovered, secret) {
+			t.Fatalf("shares %v recovered the secret with fewer than the threshold number of shares", subset)
+		}
+	})
+
+	if _, err = combineShares(map[byte][]byte{1: shares[1]}); err == nil {
+		t.Fatal("expected an error when combining a single share")
+	}
+}
+
+func TestFewerThanThresholdKeyShares(t *testing.T) {
+
+	secret := testSecret(t, 64)
+	fingerprint := secretFingerprint(secret)
+
+	shares, err := splitSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("splitSecret failed: %s", err.Error())
+	}
+	_, err = combineKeyShares([]*keyShare{
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[1]},
+		{index: 2, threshold: 3, fingerprint: fingerprint, data: shares[2]},
+	})
+	if err == nil || !strings.Contains(err.Error(), "required") {
+		t.Fatalf("expected an error with fewer than the threshold number of shares but got: %v", err)
+	}
+
+	recovered, err := combineKeyShares([]*keyShare{
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[1]},
+		{index: 3, threshold: 3, fingerprint: fingerprint, data: shares[3]},
+		{index: 5, threshold: 3, fingerprint: fingerprint, data: shares[5]},
+	})
+	if err != nil {
+		t.Fatalf("combineKeyShares failed: %s", err.Error())
+	}
+	if !bytes.Equal(recovered, secret) {
+		t.Fatal("the key shares did not recover the secret")
+	}
+}
+
+func TestDuplicateKeyShareIndexes(t *testing.T) {
+
+	secret := testSecret(t, 64)
+	fingerprint := secretFingerprint(secret)
+
+	shares, err := splitSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("splitSecret failed: %s", err.Error())
+	}
+
+	// the same share given more than once
+	// only counts once towards the threshold
+	_, err = combineKeyShares([]*keyShare{
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[1]},
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[1]},
+		{index: 2, threshold: 3, fingerprint: fingerprint, data: shares[2]},
+	})
+	if err == nil || !strings.Contains(err.Error(), "2 unique shares") {
+		t.Fatalf("expected duplicate shares to count once but got: %v", err)
+	}
+
+	// different shares with the same index are rejected
+	_, err = combineKeyShares([]*keyShare{
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[1]},
+		{index: 1, threshold: 3, fingerprint: fingerprint, data: shares[2]},
+		{index: 3, threshold: 3, fingerprint: fingerprint, data: shares[3]},
+	})
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatalf("expected an error for conflicting shares with the same index but got: %v", err)
+	}
+}
+
+func TestZeroShareIndex(t *testing.T) {
+
+	secret := testSecret(t, 16)
+
+	shares, err := splitSecret(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("splitSecret failed: %s", err.Error())
+	}
+	if _, exists := shares[0]; exists {
+		t.Fatal("splitSecret returned a share with index 0 which would reveal the secret")
+	}
+	if _, err = combineShares(map[byte][]byte{0: shares[1], 2: shares[2]}); err == nil {
+		t.Fatal("expected an error when combining a share with index 0")
+	}
+
+	share := &keyShare{index: 1, threshold: 2, fingerprint: secretFingerprint(secret), data: shares[1]}
+	text := strings.Replace(share.String(), sharePrefix+":1:", sharePrefix+":0:", 1)
+	if _, err = parseKeyShare(text); err == nil {
+		t.Fatal("expected an error when parsing a share with index 0")
+	}
+	if _, err = parseKeyShare(share.String()); err != nil {
+		t.Fatalf("unable to parse share: %s", err.Error())
+	}
+}
+
+func TestSplitSecretInvalidArguments(t *testing.T) {
+
+	secret := testSecret(t, 16)
+
+	for _, args := range [][2]int{{3, 1}, {3, 4}, {256, 2}} {
+		if _, err := splitSecret(secret, args[0], args[1]); err == nil {
+			t.Errorf("expected an error splitting into %d shares with threshold %d", args[0], args[1])
+		}
+	}
+	if _, err := splitSecret([]byte{}, 3, 2); err == nil {
+		t.Error("expected an error splitting an empty secret")
+	}
+}
+
+func TestCheckNewKeyShare(t *testing.T) {
+
+	entered := []*keyShare{
+		{index: 1, threshold: 3, fingerprint: "abc"},
+		{index: 2, threshold: 3, fingerprint: "abc"},
+	}
+
+	if err := checkNewKeyShare(entered, &keyShare{index: 3, threshold: 3, fingerprint: "abc"}); err != nil {
+		t.Fatalf("expected a new share to be accepted but got: %s", err.Error())
+	}
+	if err := checkNewKeyShare(entered, &keyShare{index: 2, threshold: 3, fingerprint: "abc"}); err == nil {
+		t.Fatal("expected a share that was already entered to be rejected")
+	}
+	if err := checkNewKeyShare(entered, &keyShare{index: 3, threshold: 3, fingerprint: "def"}); err == nil {
+		t.Fatal("expected a share of a different key backup to be rejected")
+	}
+	if err := checkNewKeyShare(entered, &keyShare{index: 3, threshold: 2, fingerprint: "abc"}); err == nil {
+		t.Fatal("expected a share with a different threshold to be rejected")
+	}
+}
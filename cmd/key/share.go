@@ -0,0 +1,88 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// prefix and version of the text encoding of a key share
+const sharePrefix = "cbks1"
+
+// a share of the owner's private key. the text encoding of
+// a share is '<prefix>:<index>:<threshold>:<fingerprint>:<data>'
+// where the fingerprint identifies the key the share belongs
+// to and is used to verify the recovered key.
+type keyShare struct {
+	index       byte
+	threshold   int
+	fingerprint string
+	data        []byte
+}
+
+func (s *keyShare) String() string {
+	return fmt.Sprintf(
+		"%s:%d:%d:%s:%s",
+		sharePrefix, s.index, s.threshold, s.fingerprint,
+		base64.RawURLEncoding.EncodeToString(s.data),
+	)
+}
+
+func parseKeyShare(text string) (*keyShare, error) {
+
+	var (
+		err error
+
+		index int
+	)
+
+	parts := strings.Split(strings.TrimSpace(text), ":")
+	if len(parts) != 5 || parts[0] != sharePrefix {
+		return nil, fmt.Errorf("not a valid key share")
+	}
+	share := &keyShare{fingerprint: parts[3]}
+	if index, err = strconv.Atoi(parts[1]); err != nil || index < 1 || index > 255 {
+		return nil, fmt.Errorf("key share has an invalid index")
+	}
+	share.index = byte(index)
+	if share.threshold, err = strconv.Atoi(parts[2]); err != nil || share.threshold < 2 {
+		return nil, fmt.Errorf("key share has an invalid threshold")
+	}
+	if share.data, err = base64.RawURLEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("key share data is not valid: %s", err.Error())
+	}
+	return share, nil
+}
+
+// encodes a PEM private key as the secret to be split. the
+// PEM block type is retained so that the original PEM can
+// be rebuilt from the secret.
+func keySecretFromPEM(keyPEM string) ([]byte, error) {
+
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("the private key is not PEM encoded")
+	}
+	secret := append([]byte(block.Type), 0)
+	return append(secret, block.Bytes...), nil
+}
+
+func keyPEMFromSecret(secret []byte) (string, error) {
+
+	i := bytes.IndexByte(secret, 0)
+	if i < 1 {
+		return "", fmt.Errorf("the recovered secret is not a private key")
+	}
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  string(secret[:i]),
+		Bytes: secret[i+1:],
+	})), nil
+}
+
+func secretFingerprint(secret []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(secret))[:16]
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var loginFlags = struct {
+	user  string
+	force bool
+}{}
+
+var loginCommand = &cobra.Command{
+	Use: "login",
+
+	Short: "Log in a user.",
+	Long: `
+Signs in a My Cloud Space user and authorizes the user to use this
+device. If a user is already logged in then that user's session will
+be reused unless the '--force' option is provided or a different user
+is given via the '--user' option. This allows authorized guest users
+of a shared device to switch identities without re-initializing the
+device.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		Login()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func Login() {
+
+	var (
+		err error
+
+		awsAuth *cbcli_auth.AWSCognitoJWT
+	)
+
+	config := cbcli_config.Config
+	deviceContext := config.DeviceContext()
+
+	if !config.Initialized() {
+		cbcli_utils.ShowErrorAndExit(
+			"The Cloud Builder client has not been initialized. Run 'cb init' to initialize it.",
+		)
+	}
+
+	forceLogin := loginFlags.force
+	if !forceLogin && len(loginFlags.user) > 0 && config.AuthContext().IsLoggedIn() {
+		// force a new login if the user to login
+		// as is not the currently logged in user
+		if awsAuth, err = cbcli_auth.NewAWSCognitoJWT(config); err != nil ||
+			awsAuth.Username() != loginFlags.user {
+			forceLogin = true
+		}
+	}
+
+	loginMessage := "Login to your My Cloud Space account."
+	if len(loginFlags.user) > 0 {
+		loginMessage = fmt.Sprintf("Login as the My Cloud Space user \"%s\".", loginFlags.user)
+	}
+	if awsAuth, err = cbcli_auth.GetAuthenticatedToken(config, forceLogin, loginMessage); err != nil {
+		logger.ErrorMessage("Login(): Authentication returned error: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("My Cloud Space user authentication failed.")
+	}
+	if len(loginFlags.user) > 0 && awsAuth.Username() != loginFlags.user {
+		// do not retain the session of
+		// the user that was not requested
+		if err = config.AuthContext().Reset(); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		deviceContext.SetLoggedInUser("", "")
+		// the command returns so that the configuration
+		// is saved before the CLI exits with an error
+		cbcli_utils.ShowErrorMessage(
+			fmt.Sprintf(
+				"Logged in as user \"%s\" but expected user \"%s\". You have been logged out",
+				awsAuth.Username(), loginFlags.user,
+			),
+		)
+		cbcli_utils.ExitCode = 1
+		return
+	}
+
+	if err = cbcli_auth.AuthorizeDeviceAndUser(config); err != nil {
+		logger.ErrorMessage("Login(): Authorizing logged in user on this device returned error: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("My Cloud Space device and user authorization failed.")
+	}
+
+	deviceName, _ := deviceContext.GetDeviceName()
+	fmt.Println()
+	if deviceContext.IsAuthorizedUser(awsAuth.Username()) {
+		cbcli_utils.ShowNoticeMessage(
+			"You are logged in as \"%s\" on device \"%s\" with role \"%s\".",
+			awsAuth.Username(), deviceName, auth.RoleFromContext(deviceContext, nil).String(),
+		)
+	} else {
+		cbcli_utils.ShowNoticeMessage(
+			"You are logged in as \"%s\" but are not yet authorized to use device \"%s\".",
+			awsAuth.Username(), deviceName,
+		)
+	}
+	fmt.Println()
+}
+
+func init() {
+	flags := loginCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&loginFlags.user, "user", "u", "", 
+		"name of the user to login as")
+	flags.BoolVarP(&loginFlags.force, "force", "f", false, 
+		"force a new login even if a user is already logged in")
+}
@@ -48,6 +48,7 @@ func ConfigureRecipe(name, cloud string) {
 		if inputForm, err = recipe.InputForm(); err != nil {
 			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
+		cbcli_utils.AssertInteractive("recipe-configuration", "configure recipe")
 		if err = ux.GetFormInput(inputForm,
 			fmt.Sprintf("Cloud Recipe Configuration for \"%s\"", name),
 			"CONFIGURATION DATA INPUT",
@@ -32,7 +32,9 @@ one of the supported public clouds.
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(listFlags.cloud) > 0 {
+		if !cbcli_utils.IsTableOutput() {
+			renderRecipesOutput(listFlags.cloud)
+		} else if len(listFlags.cloud) > 0 {
 			ListRecipesForCloud(listFlags.cloud)
 		} else {
 			ListRecipes()
@@ -40,6 +42,17 @@ one of the supported public clouds.
 	},
 }
 
+// structured output schema for a recipe. the
+// schema is documented in doc/output.md and
+// fields should only be added to it.
+type recipeOutput struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Version     string   `json:"version" yaml:"version"`
+	Description string   `json:"description" yaml:"description"`
+	Clouds      []string `json:"clouds" yaml:"clouds"`
+}
+
 func ListRecipes() {
 
 	var (
@@ -174,6 +187,41 @@ func ListRecipesForCloud(cloud string) {
 	}
 }
 
+func renderRecipesOutput(cloud string) {
+
+	cookbook := cbcli_config.Config.TargetContext().Cookbook()
+
+	output := []recipeOutput{}
+	for _, r := range cookbook.RecipeList() {
+
+		recipe := recipeOutput{
+			Name:    r.RecipeKey,
+			Version: r.CookbookVersion,
+			Clouds:  []string{},
+		}
+		if r.IsBastion {
+			recipe.Type = "space"
+		} else {
+			recipe.Type = "app"
+		}
+
+		supportsCloud := len(cloud) == 0
+		for i, c := range r.IaaSList {
+			if i == 0 {
+				recipe.Description = cookbook.GetRecipe(r.RecipeKey, c.Name()).Description()
+			}
+			if c.Name() == cloud {
+				supportsCloud = true
+			}
+			recipe.Clouds = append(recipe.Clouds, c.Name())
+		}
+		if supportsCloud {
+			output = append(output, recipe)
+		}
+	}
+	cbcli_utils.RenderOutput(output)
+}
+
 func init() {
 	flags := listCommand.Flags()
 	flags.SortFlags = false
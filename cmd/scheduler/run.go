@@ -0,0 +1,259 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_agent "github.com/appbricks/cloud-builder-cli/agent"
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	target_cmd "github.com/appbricks/cloud-builder-cli/cmd/target"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var runFlags = struct {
+	daemon   bool
+	interval time.Duration
+	logFile  string
+}{}
+
+var runCommand = &cobra.Command{
+	Use: "run",
+
+	Short: "Evaluate target schedules and suspend or resume targets.",
+	Long: `
+Evaluates the schedules of all targets at the given interval and
+suspends or resumes targets when their schedule windows open. Each
+action taken is logged with a timestamp. If windows were missed, for
+example because the machine was asleep, the scheduler catches up by
+acting on the latest missed window of each target once it resumes.
+
+By default the scheduler runs in the foreground. Provide the
+'--daemon' option to run it in the background with its output
+written to a log file.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if runFlags.daemon {
+			StartSchedulerDaemon()
+		} else {
+			RunScheduler()
+		}
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+func RunScheduler() {
+
+	if runFlags.interval < time.Second {
+		cbcli_utils.ShowErrorAndExit("The scheduler interval must be at least one second.")
+	}
+	logEvent("Scheduler started evaluating target schedules every %s.", runFlags.interval)
+
+	for {
+		evaluateSchedules(time.Now())
+		time.Sleep(runFlags.interval)
+	}
+}
+
+// evaluates the schedules of all targets and acts
+// on the latest window that has opened since the
+// previous evaluation of each schedule
+func evaluateSchedules(now time.Time) {
+
+	var (
+		err error
+
+		keys     []string
+		settings *cbcli_config.TargetSettings
+		action   string
+		at       time.Time
+	)
+
+	// settings are read from disk on each
+	// evaluation so schedule changes made by
+	// other commands are picked up
+	if keys, err = cbcli_config.TargetSettingsKeys(); err != nil {
+		logEvent("ERROR: Unable to load target schedules: %s", err.Error())
+		return
+	}
+	sort.Strings(keys)
+
+	configReloaded := false
+	for _, key := range keys {
+		if settings, err = cbcli_config.GetTargetSettings(key); err != nil {
+			logEvent("ERROR: Unable to load schedule of target \"%s\": %s", key, err.Error())
+			continue
+		}
+		schedule := settings.Schedule
+		if schedule == nil {
+			continue
+		}
+		if action, at, err = schedule.Due(schedule.LastEvaluated, now); err != nil {
+			logEvent("ERROR: Invalid schedule for target \"%s\": %s", key, err.Error())
+			continue
+		}
+
+		if len(action) > 0 {
+			if !configReloaded {
+				// pick up target changes
+				// made by other commands
+				if err = cbcli_config.ReloadConfig(); err != nil {
+					logEvent("ERROR: Unable to reload the configuration: %s", err.Error())
+					return
+				}
+				configReloaded = true
+			}
+			if at.Before(now.Add(-time.Minute)) {
+				logEvent("Catching up on missed %s window of target \"%s\" at %s.", action, key, at.Format(time.RFC1123))
+			}
+			applyScheduleAction(key, action)
+		}
+
+		// only the evaluated target's schedule is
+		// updated so that changes made to other
+		// targets by other commands are retained
+		if err = cbcli_config.UpdateTargetSettings(key, func(settings *cbcli_config.TargetSettings) error {
+			if settings.Schedule != nil {
+				settings.Schedule.LastEvaluated = now
+			}
+			return nil
+		}); err != nil {
+			logEvent("ERROR: Unable to save schedule of target \"%s\": %s", key, err.Error())
+		}
+	}
+}
+
+func applyScheduleAction(key, action string) {
+
+	var (
+		err error
+
+		tgt *target.Target
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(key); err != nil || tgt == nil {
+		logEvent("Skipping %s of target \"%s\" as it no longer exists.", action, key)
+		return
+	}
+
+	status := tgt.Status()
+	switch {
+	case status == target.Undeployed:
+		logEvent("Skipping %s of target \"%s\" as it has not been deployed.", action, key)
+
+	case action == cbcli_config.ScheduleSuspend:
+		if status == target.Shutdown {
+			logEvent("Target \"%s\" is already suspended.", key)
+			return
+		}
+		logEvent("Suspending target \"%s\".", key)
+		if err = target_cmd.SuspendTargetInstances(tgt, nil, "suspend window of the target's schedule"); err != nil {
+			logEvent("ERROR: Suspending target \"%s\" failed: %s", key, err.Error())
+			return
+		}
+		logEvent("Target \"%s\" has been suspended.", key)
+
+	case action == cbcli_config.ScheduleResume:
+		if status == target.Running {
+			logEvent("Target \"%s\" is already running.", key)
+			return
+		}
+		logEvent("Resuming target \"%s\".", key)
+		if err = target_cmd.ResumeTargetInstances(tgt, nil); err != nil {
+			logEvent("ERROR: Resuming target \"%s\" failed: %s", key, err.Error())
+			return
+		}
+		logEvent("Target \"%s\" has been resumed.", key)
+	}
+}
+
+// starts the scheduler in a background process. the
+// passphrase is handed to the process via its stdin
+// so that it is not exposed in its environment.
+func StartSchedulerDaemon() {
+
+	var (
+		err error
+
+		executable string
+		logFile    *os.File
+		stdin      io.WriteCloser
+	)
+
+	if executable, err = os.Executable(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	logFilePath := runFlags.logFile
+	if len(logFilePath) == 0 {
+		logFilePath = filepath.Join(filepath.Dir(cbcli_config.ConfigFile), "scheduler.log")
+	}
+	if logFile, err = os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	defer logFile.Close()
+
+	daemonCmd := exec.Command(
+		executable, "scheduler", "run",
+		"--interval", runFlags.interval.String(),
+		"--config", cbcli_config.ConfigFile,
+		"--non-interactive",
+	)
+	daemonCmd.Env = append(os.Environ(), "__CB_PASSPHRASE_STDIN__=1")
+	daemonCmd.Stdout = logFile
+	daemonCmd.Stderr = logFile
+	cbcli_agent.Detach(daemonCmd)
+
+	if stdin, err = daemonCmd.StdinPipe(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = daemonCmd.Start(); err != nil {
+		logger.DebugMessage("StartSchedulerDaemon(): Failed to start scheduler process: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("Unable to start the scheduler.")
+	}
+	_, err = fmt.Fprintln(stdin, cbcli_config.Passphrase)
+	stdin.Close()
+	if err != nil {
+		logger.DebugMessage("StartSchedulerDaemon(): Failed to pass passphrase to scheduler process: %s", err.Error())
+		cbcli_utils.ShowErrorAndExit("Unable to start the scheduler.")
+	}
+	pid := daemonCmd.Process.Pid
+	_ = daemonCmd.Process.Release()
+
+	fmt.Println()
+	cbcli_utils.ShowNoteMessage(
+		"The scheduler has been started in the background with pid %d. Its output is logged to '%s'.",
+		pid, logFilePath,
+	)
+	fmt.Println()
+}
+
+func logEvent(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	logger.DebugMessage("Scheduler: %s", message)
+	fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), message)
+}
+
+func init() {
+	flags := runCommand.Flags()
+	flags.SortFlags = false
+	flags.BoolVarP(&runFlags.daemon, "daemon", "d", false,
+		"run the scheduler in the background")
+	flags.DurationVarP(&runFlags.interval, "interval", "i", time.Minute,
+		"interval at which schedules are evaluated")
+	flags.StringVarP(&runFlags.logFile, "log-file", "l", "",
+		"file the daemon's output is logged to (default \"scheduler.log\"\nin the configuration directory)")
+}
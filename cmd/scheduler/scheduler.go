@@ -0,0 +1,20 @@
+package scheduler
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var SchedulerCommands = &cobra.Command{
+	Use: "scheduler",
+
+	Short: "Suspend and resume targets on a schedule.",
+	Long: `
+The scheduler suspends and resumes targets according to the schedules
+attached to them via 'cb target schedule'. The scheduler can be run in
+the foreground or as a background daemon.
+`,
+}
+
+func init() {
+	SchedulerCommands.AddCommand(runCommand)
+}
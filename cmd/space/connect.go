@@ -77,7 +77,9 @@ func ConnectSpace(space userspace.SpaceNode) {
 	}
 
 	if len(connectFlags.managedDevice) == 0 {
-		connectToSpaceNetwork(space)
+		if connectToSpaceNetwork(space) {
+			suspendIdleSpace(space)
+		}
 	} else {
 		// if managed device option is provided we 
 		// simply create download a space connection 
@@ -87,7 +89,10 @@ func ConnectSpace(space userspace.SpaceNode) {
 	}
 }
 
-func connectToSpaceNetwork(space userspace.SpaceNode) {
+// connects to the space network and returns true if the
+// connection was terminated because the space's idle
+// policy requires it to be suspended
+func connectToSpaceNetwork(space userspace.SpaceNode) bool {
 	
 	var (
 		err error
@@ -103,6 +108,8 @@ func connectToSpaceNetwork(space userspace.SpaceNode) {
 		key keyboard.Key
 
 		sent, recd int64
+
+		idleSuspend bool
 	)
 
 	deviceContext := cbcli_config.Config.DeviceContext()
@@ -271,11 +278,17 @@ func connectToSpaceNetwork(space userspace.SpaceNode) {
 		disconnect <- true
 	}()
 
+	// the daemon's traffic counters are sampled
+	// to enforce the space's idle policy if any
+	watcher := spaceIdleWatcher(space)
+	warned := false
+
 	fmt.Println()
+	suffix := " Press CTRL-x or CTRL-c to disconnect."
 	s := spinner.New(
 		spinner.CharSets[cbcli_config.SpinnerNetworkType], 
 		100*time.Millisecond,
-		spinner.WithSuffix(" Press CTRL-x or CTRL-c to disconnect."),
+		spinner.WithSuffix(suffix),
 		spinner.WithFinalMSG("Connection to space network mesh has been terminated.\n"),
 		spinner.WithHiddenCursor(true),
 	)
@@ -286,6 +299,30 @@ func connectToSpaceNetwork(space userspace.SpaceNode) {
 			if sent, recd, err = tsd.BytesTransmitted(); err != nil {
 				logger.DebugMessage("Error retrieving tailscale connection status: %s", err.Error())
 				s.Prefix = "\nUnable to retrieve connection status.\n"
+
+			} else if watcher != nil {
+				idle := watcher.sample(sent, recd, time.Now())
+				switch {
+				case watcher.shouldSuspend(idle):
+					idleSuspend = true
+				case watcher.shouldWarn(idle):
+					if !warned {
+						// ring the terminal bell
+						fmt.Print("\a")
+						warned = true
+					}
+					s.Suffix = suffix + color.Red.Render(
+						fmt.Sprintf(
+							" No mesh traffic for %s. Space will be suspended in %s.",
+							idle.Truncate(time.Minute),
+							(watcher.policy.SuspendAfter - idle).Truncate(time.Second),
+						),
+					)
+				case warned:
+					// traffic has resumed
+					s.Suffix = suffix
+					warned = false
+				}
 			}
 			if logrus.GetLevel()  == logrus.TraceLevel {
 				if s.Prefix, err = tsd.WireguardStatusText(); err != nil {
@@ -310,10 +347,20 @@ func connectToSpaceNetwork(space userspace.SpaceNode) {
 		case <-disconnect:
 			s.Stop()
 			fmt.Println()
-			return
+			return false
 		case <-time.After(time.Millisecond * 500):					
 		}
 		setStatus()
+
+		if idleSuspend {
+			s.Stop()
+			fmt.Println()
+			cbcli_utils.ShowNoticeMessage(
+				"Space \"%s\" has been idle for %s and will be suspended.",
+				space.GetSpaceName(), watcher.policy.SuspendAfter,
+			)
+			return true
+		}
 	}
 }
 
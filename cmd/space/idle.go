@@ -0,0 +1,294 @@
+package space
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/appbricks/cloud-builder/userspace"
+	"github.com/mevansam/goutils/logger"
+	"github.com/mevansam/goutils/utils"
+
+	target_cmd "github.com/appbricks/cloud-builder-cli/cmd/target"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var idlePolicyFlags = struct {
+	commonFlags
+
+	suspendAfter time.Duration
+	warnBefore   time.Duration
+	minTraffic   int64
+	clear        bool
+}{}
+
+var idlePolicyCommand = &cobra.Command{
+	Use: "idle-policy [recipe] [cloud] [deployment name]",
+
+	Short: "Suspend a space when its mesh network is idle.",
+	Long: `
+Sets a policy that suspends a space once there has been no traffic on
+its mesh network for the given duration. The policy is enforced while
+an admin of the space is connected to the space's mesh network via
+'cb space connect' on this device, by sampling the traffic counters of
+that connection. The connected session is warned before the space is
+suspended and the reason the space was suspended is recorded and
+shown by 'cb target show'. Only the traffic of the connection on this
+device is sampled and users connected from other devices are not
+warned. For example the following policy will suspend a space if
+there has been no mesh traffic for 2 hours.
+
+  cb space idle-policy vpn aws mytarget -r us-east-1 \
+    --suspend-after 2h --warn-before 10m
+
+Traffic below the minimum rate, such as the mesh network's keep
+alives, does not count as activity. Run this sub-command without any
+options to view the space's current idle policy.
+`,
+
+	PreRun: authorizeSpaceNode(auth.NewRoleMask(auth.Admin), &(idlePolicyFlags.commonFlags)),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		SetIdlePolicy(cmd, spaceNode)
+	},
+	Args: cobra.ExactArgs(3),
+}
+
+func SetIdlePolicy(cmd *cobra.Command, space userspace.SpaceNode) {
+
+	var (
+		err error
+
+		tgt      *target.Target
+		settings *cbcli_config.TargetSettings
+	)
+
+	// the space needs to be suspended via its
+	// target so the policy can only be set for
+	// spaces launched from this device
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(space.Key()); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Space \"%s\" was not launched from this device. Idle policies can only be set for local space targets",
+				space.GetSpaceName(),
+			),
+		)
+	}
+	if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	flags := cmd.Flags()
+	changed := flags.Changed("suspend-after") || flags.Changed("warn-before") || flags.Changed("min-traffic")
+	if idlePolicyFlags.clear {
+		if changed {
+			cbcli_utils.ShowErrorAndExit("The '--clear' option cannot be combined with other idle policy options.")
+		}
+		settings.IdlePolicy = nil
+		if err = cbcli_config.UpdateTargetSettings(tgt.Key(), func(s *cbcli_config.TargetSettings) error {
+			s.IdlePolicy = settings.IdlePolicy
+			return nil
+		}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		fmt.Println()
+		cbcli_utils.ShowInfoMessage("The idle policy of space \"%s\" has been removed.", space.GetSpaceName())
+		fmt.Println()
+		return
+	}
+
+	if changed {
+		policy := settings.IdlePolicy
+		if policy == nil {
+			if !flags.Changed("suspend-after") {
+				cbcli_utils.ShowErrorAndExit("The '--suspend-after' option is required when creating an idle policy.")
+			}
+			policy = &cbcli_config.IdlePolicy{
+				WarnBefore: idlePolicyFlags.warnBefore,
+				MinTraffic: idlePolicyFlags.minTraffic,
+			}
+		}
+		if flags.Changed("suspend-after") {
+			policy.SuspendAfter = idlePolicyFlags.suspendAfter
+		}
+		if flags.Changed("warn-before") {
+			policy.WarnBefore = idlePolicyFlags.warnBefore
+		}
+		if flags.Changed("min-traffic") {
+			policy.MinTraffic = idlePolicyFlags.minTraffic
+		}
+		if policy.SuspendAfter < time.Minute {
+			cbcli_utils.ShowErrorAndExit("A space must be idle for at least a minute before it is suspended.")
+		}
+		if policy.WarnBefore < 0 || policy.WarnBefore >= policy.SuspendAfter {
+			cbcli_utils.ShowErrorAndExit("The warning must be given after the space becomes idle and before it is suspended.")
+		}
+		if policy.MinTraffic < 0 {
+			cbcli_utils.ShowErrorAndExit("The minimum traffic cannot be negative.")
+		}
+
+		settings.IdlePolicy = policy
+		if err = cbcli_config.UpdateTargetSettings(tgt.Key(), func(s *cbcli_config.TargetSettings) error {
+			s.IdlePolicy = settings.IdlePolicy
+			return nil
+		}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	fmt.Println()
+	if settings.IdlePolicy == nil {
+		cbcli_utils.ShowNoticeMessage("Space \"%s\" does not have an idle policy.", space.GetSpaceName())
+	} else {
+		fmt.Printf("Suspend after: %s\n", settings.IdlePolicy.SuspendAfter)
+		fmt.Printf("Warn before:   %s\n", settings.IdlePolicy.WarnBefore)
+		fmt.Printf("Min traffic:   %s/min\n", utils.ByteCountIEC(settings.IdlePolicy.MinTraffic))
+	}
+	if settings.Suspended != nil {
+		fmt.Printf(
+			"\nLast suspended: %s (at %s)\n",
+			settings.Suspended.Reason,
+			settings.Suspended.At.Local().Format(time.RFC1123),
+		)
+	}
+	fmt.Println()
+}
+
+// idleWatcher tracks the traffic on the space mesh
+// network and determines how long it has been idle
+type idleWatcher struct {
+	policy *cbcli_config.IdlePolicy
+
+	// traffic counters at the start
+	// of the current sample window
+	windowStart time.Time
+	windowBytes int64
+
+	lastActive time.Time
+}
+
+func newIdleWatcher(policy *cbcli_config.IdlePolicy) *idleWatcher {
+	return &idleWatcher{
+		policy: policy,
+	}
+}
+
+// returns a watcher for the space's idle policy if one has
+// been set and the logged in user is allowed to suspend the
+// space from this device. otherwise nil is returned.
+func spaceIdleWatcher(space userspace.SpaceNode) *idleWatcher {
+
+	var (
+		err error
+
+		tgt      *target.Target
+		settings *cbcli_config.TargetSettings
+	)
+
+	if !auth.NewRoleMask(auth.Admin).LoggedInUserHasRole(cbcli_config.Config.DeviceContext(), space) {
+		return nil
+	}
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(space.Key()); err != nil || tgt == nil {
+		return nil
+	}
+	if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil || settings.IdlePolicy == nil {
+		return nil
+	}
+	return newIdleWatcher(settings.IdlePolicy)
+}
+
+// samples the given mesh traffic counters and returns how long
+// the mesh network has been idle. traffic is measured over one
+// minute windows and a window with less than the policy's
+// minimum traffic is considered idle.
+func (w *idleWatcher) sample(sent, recd int64, now time.Time) time.Duration {
+
+	total := sent + recd
+	if w.windowStart.IsZero() || total < w.windowBytes {
+		// first sample or the counters
+		// were reset by the daemon
+		w.windowStart, w.windowBytes = now, total
+		if w.lastActive.IsZero() {
+			w.lastActive = now
+		}
+		return now.Sub(w.lastActive)
+	}
+
+	if elapsed := now.Sub(w.windowStart); elapsed >= time.Minute {
+		if (total-w.windowBytes)*int64(time.Minute/time.Second) >= w.policy.MinTraffic*int64(elapsed/time.Second) {
+			w.lastActive = now
+		}
+		w.windowStart, w.windowBytes = now, total
+	}
+	return now.Sub(w.lastActive)
+}
+
+// returns true if connected users should be warned
+// that the space is about to be suspended
+func (w *idleWatcher) shouldWarn(idle time.Duration) bool {
+	return idle >= w.policy.SuspendAfter-w.policy.WarnBefore
+}
+
+// returns true if the space should be suspended
+func (w *idleWatcher) shouldSuspend(idle time.Duration) bool {
+	return idle >= w.policy.SuspendAfter
+}
+
+// suspends a space whose mesh network has been idle
+// for longer than the duration of its idle policy
+func suspendIdleSpace(space userspace.SpaceNode) {
+
+	var (
+		err error
+
+		tgt      *target.Target
+		settings *cbcli_config.TargetSettings
+	)
+
+	// wait for the network services to shut
+	// down before showing the suspend progress
+	if cbcli_config.ShutdownSpinner != nil {
+		cbcli_config.ShutdownSpinner.Stop()
+		cbcli_config.ShutdownSpinner = nil
+	}
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(space.Key()); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to suspend space \"%s\" as its target could not be found", space.GetSpaceName()),
+		)
+	}
+	if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil || settings.IdlePolicy == nil {
+		return
+	}
+	fmt.Println()
+	if err = target_cmd.SuspendTargetInstances(
+		tgt, nil,
+		fmt.Sprintf("no mesh traffic for %s", settings.IdlePolicy.SuspendAfter),
+	); err != nil {
+		logger.ErrorMessage("suspendIdleSpace(): Suspending idle space \"%s\" failed: %s", tgt.Key(), err.Error())
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Suspending idle space \"%s\" failed: %s", space.GetSpaceName(), err.Error()),
+		)
+	}
+	cbcli_utils.ShowInfoMessage("Space \"%s\" has been suspended.", space.GetSpaceName())
+	fmt.Println()
+}
+
+func init() {
+	flags := idlePolicyCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(idlePolicyFlags.commonFlags))
+
+	flags.DurationVar(&idlePolicyFlags.suspendAfter, "suspend-after", 2*time.Hour,
+		"suspend the space after its mesh network has been idle for this duration")
+	flags.DurationVar(&idlePolicyFlags.warnBefore, "warn-before", 10*time.Minute,
+		"warn connected users this long before the space is suspended")
+	flags.Int64Var(&idlePolicyFlags.minTraffic, "min-traffic", 16*1024,
+		"bytes per minute of mesh traffic below which the mesh network\nis considered idle")
+	flags.BoolVar(&idlePolicyFlags.clear, "clear", false,
+		"remove the space's idle policy")
+}
@@ -0,0 +1,105 @@
+package space
+
+import (
+	"testing"
+	"time"
+
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+)
+
+func testIdlePolicy() *cbcli_config.IdlePolicy {
+	return &cbcli_config.IdlePolicy{
+		SuspendAfter: 10 * time.Minute,
+		WarnBefore:   2 * time.Minute,
+		MinTraffic:   1024,
+	}
+}
+
+func TestIdleWatcherActiveTraffic(t *testing.T) {
+
+	watcher := newIdleWatcher(testIdlePolicy())
+	start := time.Now()
+
+	// 2KiB per minute is above the minimum traffic
+	var total int64
+	for i := 0; i <= 30; i++ {
+		now := start.Add(time.Duration(i) * 30 * time.Second)
+		total += 1024
+		idle := watcher.sample(total, 0, now)
+		if idle > time.Minute {
+			t.Fatalf("expected the mesh network to be active after %s but it has been idle for %s", now.Sub(start), idle)
+		}
+		if watcher.shouldWarn(idle) || watcher.shouldSuspend(idle) {
+			t.Fatalf("unexpected warning or suspension after %s", now.Sub(start))
+		}
+	}
+}
+
+func TestIdleWatcherNoTraffic(t *testing.T) {
+
+	watcher := newIdleWatcher(testIdlePolicy())
+	start := time.Now()
+
+	var (
+		warnedAt, suspendedAt time.Duration
+	)
+	for i := 0; i <= 30; i++ {
+		now := start.Add(time.Duration(i) * 30 * time.Second)
+		// keep alives below the minimum traffic
+		idle := watcher.sample(int64(i*100), int64(i*100), now)
+		if idle != now.Sub(start) {
+			t.Fatalf("expected the mesh network to be idle for %s but got %s", now.Sub(start), idle)
+		}
+		if warnedAt == 0 && watcher.shouldWarn(idle) {
+			warnedAt = idle
+		}
+		if suspendedAt == 0 && watcher.shouldSuspend(idle) {
+			suspendedAt = idle
+		}
+	}
+	if warnedAt != 8*time.Minute {
+		t.Fatalf("expected a warning after 8m but got it after %s", warnedAt)
+	}
+	if suspendedAt != 10*time.Minute {
+		t.Fatalf("expected a suspension after 10m but got it after %s", suspendedAt)
+	}
+}
+
+func TestIdleWatcherTrafficResumes(t *testing.T) {
+
+	watcher := newIdleWatcher(testIdlePolicy())
+	start := time.Now()
+
+	watcher.sample(0, 0, start)
+	idle := watcher.sample(0, 0, start.Add(9*time.Minute))
+	if !watcher.shouldWarn(idle) || watcher.shouldSuspend(idle) {
+		t.Fatalf("expected a warning only after being idle for %s", idle)
+	}
+
+	// traffic in the last window makes
+	// the mesh network active again
+	if idle = watcher.sample(0, 4096, start.Add(10*time.Minute)); idle != 0 {
+		t.Fatalf("expected the mesh network to be active but it has been idle for %s", idle)
+	}
+	if watcher.shouldWarn(idle) {
+		t.Fatal("unexpected warning once traffic has resumed")
+	}
+}
+
+func TestIdleWatcherCounterReset(t *testing.T) {
+
+	watcher := newIdleWatcher(testIdlePolicy())
+	start := time.Now()
+
+	watcher.sample(1<<20, 1<<20, start)
+	// counters that are reset by the mesh daemon
+	// do not count as traffic nor reset the idle time
+	idle := watcher.sample(0, 0, start.Add(5*time.Minute))
+	if idle != 5*time.Minute {
+		t.Fatalf("expected the mesh network to be idle for 5m but got %s", idle)
+	}
+	idle = watcher.sample(100, 100, start.Add(7*time.Minute))
+	if idle != 7*time.Minute {
+		t.Fatalf("expected the mesh network to be idle for 7m but got %s", idle)
+	}
+}
@@ -41,6 +41,22 @@ been configured for.
 	},
 }
 
+// structured output schema for a space node. the
+// schema is documented in doc/output.md and fields
+// should only be added to it.
+type spaceOutput struct {
+	Key        string `json:"key" yaml:"key"`
+	SpaceID    string `json:"spaceID" yaml:"spaceID"`
+	Recipe     string `json:"recipe" yaml:"recipe"`
+	Cloud      string `json:"cloud" yaml:"cloud"`
+	Region     string `json:"region" yaml:"region"`
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version" yaml:"version"`
+	Owned      bool   `json:"owned" yaml:"owned"`
+	AccessType string `json:"accessType" yaml:"accessType"`
+	Status     string `json:"status" yaml:"status"`
+}
+
 type spaceSelectorArgs struct {
 	space      userspace.SpaceNode
 	accessType auth.Role
@@ -85,14 +101,14 @@ var spaceSelector = cbcli_utils.OptionSelector{
 		{
 			Text: " - Suspend",
 			Command: func(data interface{}) error { 
-				target.SuspendTarget(data.(userspace.SpaceNode).Key())
+				target.SuspendTarget(data.(userspace.SpaceNode).Key(), nil)
 				return nil
 			},
 		},
 		{
 			Text: " - Resume",
 			Command: func(data interface{}) error { 
-				target.ResumeTarget(data.(userspace.SpaceNode).Key())
+				target.ResumeTarget(data.(userspace.SpaceNode).Key(), nil)
 				return nil
 			},
 		},
@@ -145,6 +161,10 @@ func ListSpaces() {
 
 	// shared spaces a retrieved after login for target commands
 	spaces := cbcli_config.SpaceNodes.GetAllSpaces()
+	if !cbcli_utils.IsTableOutput() {
+		renderSpacesOutput(spaces)
+		return
+	}
 	if (len(spaces) > 0) {
 		fmt.Println("\nYou have access to the following shared spaces.")
 		sharedSpacesTable := buildSpacesTable(spaces, &spaceIndex, &spaceSubCommandArgs)
@@ -160,6 +180,7 @@ func ListSpaces() {
 			optionList[i] = strconv.Itoa(i + 1)
 		}
 		if response = cbcli_utils.GetUserInputFromList(
+			"select-space",
 			"Enter # of node to execute sub-command on or (q)uit: ",
 			"", optionList, false); response == "q" {
 			fmt.Println()
@@ -293,6 +314,28 @@ func buildSpacesTable(
 	return table
 }
 
+func renderSpacesOutput(spaces []userspace.SpaceNode) {
+
+	deviceContext := cbcli_config.Config.DeviceContext()
+
+	output := []spaceOutput{}
+	for _, space := range spaces {
+		output = append(output, spaceOutput{
+			Key:        space.Key(),
+			SpaceID:    space.GetSpaceID(),
+			Recipe:     space.GetRecipe(),
+			Cloud:      space.GetIaaS(),
+			Region:     space.GetRegion(),
+			Name:       space.GetSpaceName(),
+			Version:    space.GetVersion(),
+			Owned:      space.IsSpaceOwned(),
+			AccessType: auth.RoleFromContext(deviceContext, space).String(),
+			Status:     space.GetStatus(),
+		})
+	}
+	cbcli_utils.RenderOutput(output)
+}
+
 func formatStatus(status string) string {
 
 	var (
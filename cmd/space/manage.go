@@ -165,6 +165,7 @@ func ManageSpace(space userspace.SpaceNode) {
 				optionList[i] = strconv.Itoa(i + 1)
 			}
 			if response = cbcli_utils.GetUserInputFromList(
+				"select-space-user",
 				"Enter # of the user to manage or (q)uit: ",
 				"", optionList, false); response == "q" {
 				fmt.Println()
@@ -371,6 +372,7 @@ func selectDeviceFromList(prompt string, deviceNames *[]string) string {
 	
 	fmt.Println()
 	return cbcli_utils.GetUserInputFromList(
+		"select-device",
 		fmt.Sprintf("User <TAB> to scroll through and select from list of devices to %s: ", prompt),
 		"", *deviceNames, true)
 }
@@ -31,6 +31,7 @@ func init() {
 	SpaceCommands.AddCommand(listCommand)
 	SpaceCommands.AddCommand(connectCommand)
 	SpaceCommands.AddCommand(manageCommand)
+	SpaceCommands.AddCommand(idlePolicyCommand)
 }
 
 type commonFlags struct {
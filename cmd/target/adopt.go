@@ -0,0 +1,258 @@
+package target
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/appbricks/mycloudspace-client/api"
+	"github.com/appbricks/mycloudspace-client/mycscloud"
+	"github.com/mevansam/goforms/forms"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var adoptFlags = struct {
+	region  string
+	name    string
+	space   string
+	backend map[string]string
+	values  string
+}{}
+
+var adoptCommand = &cobra.Command{
+	Use: "adopt [recipe] [cloud]",
+
+	Short: "Adopt a deployment made outside of this CLI as a target.",
+	Long: `
+Creates a target for a recipe that has already been deployed, for
+example by a teammate or from a device whose configuration has been
+lost, so that it can be managed by the CLI. The deployment's state is
+read from the recipe's backend storage which is configured via the
+'--backend' option or interactively. The backend storage is only read
+and the deployment will not be adopted if it does not exist. Provide
+the same recipe inputs the deployment was launched with so that
+re-launching the target does not change its resources.
+
+Once adopted the target is registered with your MyCS account and can
+be shown, suspended, resumed, accessed via ssh and deleted like any
+other target.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		AdoptTarget(args[0], args[1])
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+func AdoptTarget(recipeKey, iaasName string) {
+
+	var (
+		err error
+
+		tgt, spaceTgt *target.Target
+		bldr          *target.Builder
+
+		providerInputForm,
+		recipeInputForm,
+		backendInputForm forms.InputForm
+
+		field *forms.InputField
+	)
+	config := cbcli_config.Config
+	context := config.TargetContext()
+
+	values := loadTargetValues(adoptFlags.values)
+	if values != nil && len(adoptFlags.space) == 0 {
+		adoptFlags.space = values.Space
+	}
+
+	if tgt, err = context.NewTarget(recipeKey, iaasName); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Unknown recipe \"%s\" for cloud \"%s\" given to the adopt "+
+					"command. Run 'cb recipe list' to get list of available recipes.",
+				recipeKey, iaasName,
+			),
+		)
+	}
+	if _, err = tgt.UpdateKeys(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if !tgt.Provider.IsValid() {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Credentials for the '%s' cloud provider have not been configured. "+
+					"Run 'cb cloud configure %s' to configure the cloud provider.",
+				iaasName, iaasName,
+			),
+		)
+	}
+
+	if providerInputForm, err = tgt.Provider.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if recipeInputForm, err = tgt.Recipe.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	setInput := func(inputForm forms.InputForm, name, value string) {
+		if field, err = inputForm.GetInputField(name); err == nil {
+			err = field.SetValue(&value)
+		}
+		if err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Unable to set '%s' to '%s': %s", name, value, err.Error()))
+		}
+	}
+	if len(adoptFlags.region) > 0 {
+		setInput(providerInputForm, "region", adoptFlags.region)
+		if _, err = recipeInputForm.GetInputField("region"); err == nil {
+			setInput(recipeInputForm, "region", adoptFlags.region)
+		}
+	}
+	setInput(recipeInputForm, "name", adoptFlags.name)
+
+	// applications are attached to the space
+	// they were deployed to
+	if !tgt.Recipe.IsBastion() {
+		if spaceTgt = context.TargetSet().GetTarget(adoptFlags.space); spaceTgt == nil || !spaceTgt.Recipe.IsBastion() {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Invalid space target key '%s'. Provide the space the application was deployed to with '--space'.", adoptFlags.space))
+		}
+		tgt.DependentTargets = []string{adoptFlags.space}
+	}
+	if context.HasTarget(tgt.Key()) {
+		cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Target \"%s\" already exists.", tgt.Key()))
+	}
+
+	// set the backend values that locate the deployment's state
+	// before the backend is configured with the target's recipe
+	// and values
+	if tgt.Backend == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Recipe '%s' does not have a backend its deployment state can be read from.", recipeKey))
+	}
+	if err = tgt.Backend.Configure(
+		tgt.Provider,
+		tgt.DeploymentName(), tgt.RecipeName,
+	); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if backendInputForm, err = tgt.Backend.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	names := make([]string, 0, len(adoptFlags.backend))
+	for name := range adoptFlags.backend {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		setInput(backendInputForm, name, adoptFlags.backend[name])
+	}
+
+	configureTarget(tgt, values, true, "target-undeployed")
+	if !context.HasTarget(tgt.Key()) {
+		return
+	}
+
+	// read the deployment's outputs from its state
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(" Reading the deployment state from the target's backend."),
+		spinner.WithFinalMSG(""),
+		spinner.WithHiddenCursor(true),
+	)
+	s.Start()
+	if bldr, err = newAdoptedTargetBuilder(tgt); err == nil {
+		output := bldr.Output()
+		logger.TraceMessage("Adopted deployment output: %# v", output)
+
+		tgt.Output = output
+		tgt.CookbookVersion = tgt.Recipe.CookbookVersion()
+		if tgt.Status() == target.Undeployed {
+			err = fmt.Errorf("no deployed resources were found in the backend state")
+		}
+	}
+	s.Stop()
+	if err != nil {
+		context.DeleteTarget(tgt.Key())
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Unable to adopt deployment \"%s\": %s. Check the backend configuration and recipe inputs.",
+				tgt.DeploymentName(), err.Error(),
+			),
+		)
+	}
+	context.SaveTarget(tgt.Key(), tgt)
+
+	// add target to MyCS account
+	if tgt.Recipe.IsBastion() {
+		spaceAPI := mycscloud.NewSpaceAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if err = spaceAPI.AddSpace(tgt, true); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	} else {
+		appAPI := mycscloud.NewAppAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if err = appAPI.AddApp(tgt, spaceTgt.GetSpaceID()); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	fmt.Print(color.Green.Render(fmt.Sprintf("Deployment \"%s\" has been adopted as target \"%s\".\n", tgt.DeploymentName(), tgt.Key())))
+	showNodeInfo(tgt)
+}
+
+// creates a builder for the target that only reads the
+// deployment's existing backend state. unlike launching a
+// target the backend's state storage resources are not
+// prepared, so initializing the builder fails if the
+// backend's storage does not exist.
+func newAdoptedTargetBuilder(tgt *target.Target) (*target.Builder, error) {
+
+	var (
+		err error
+
+		bldr *target.Builder
+	)
+
+	if bldr, err = tgt.NewBuilder(cbcli_config.Config.ContextVars(), io.Discard, os.Stderr); err != nil {
+		return nil, err
+	}
+	if err = bldr.Initialize(); err != nil {
+		return nil, fmt.Errorf("unable to read the backend state: %s", err.Error())
+	}
+	return bldr, nil
+}
+
+func init() {
+	flags := adoptCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&adoptFlags.name, "name", "n", "",
+		"deployment name the recipe was deployed with")
+	flags.StringVarP(&adoptFlags.region, "region", "r", "",
+		"region the recipe was deployed to")
+	flags.StringVarP(&adoptFlags.space, "space", "s", "",
+		"space target key an application was deployed to\n(format <recipe>/<cloud>/<region>/<name>)")
+	flags.StringToStringVarP(&adoptFlags.backend, "backend", "b", nil,
+		"backend configuration values that locate the deployment's\nstate (format <name>=<value>,...)")
+	flags.StringVarP(&adoptFlags.values, "values", "f", "",
+		"yaml or json file with the target's configuration values\n(the target is saved without prompting for input)")
+	adoptCommand.MarkFlagRequired("name")
+}
@@ -0,0 +1,256 @@
+package target
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/goforms/forms"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var applyCommand = &cobra.Command{
+	Use: "apply [plan file]",
+
+	Short: "Apply a launch plan saved by 'cb target launch --plan-out'.",
+	Long: `
+Launches a target by applying a launch plan that was saved for review
+with 'cb target launch --plan-out'. The plan will not be applied if
+the target's configuration or the cookbook has changed since the plan
+was saved. The target's launch plan is also computed again and the
+plan will not be applied if it differs from the saved plan, which
+would be the case if the target's deployed resources or backend state
+have changed.
+
+The saved plan is a record for review and not a Terraform plan file.
+The launch computes its plan again when it is applied, so a change to
+the target's resources made after the plan is verified and before it
+is applied will not be caught before it is applied. If the plan that
+was applied differs from the saved plan, or cannot be determined from
+the launch output, the command fails once the launch completes.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ApplyLaunchPlan(args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// a launch plan saved for review
+type launchPlan struct {
+	TargetKey       string    `yaml:"targetKey"`
+	CookbookVersion string    `yaml:"cookbookVersion"`
+	InputHash       string    `yaml:"inputHash"`
+	PlanHash        string    `yaml:"planHash"`
+	Rebuild         bool      `yaml:"rebuild,omitempty"`
+	CleanRebuild    bool      `yaml:"cleanRebuild,omitempty"`
+	CreatedAt       time.Time `yaml:"createdAt"`
+
+	// plan output for review
+	Plan string `yaml:"plan"`
+}
+
+func ApplyLaunchPlan(planFile string) {
+
+	var (
+		err error
+
+		data []byte
+		plan launchPlan
+
+		tgt       *target.Target
+		bldr      *target.Builder
+		inputHash string
+		resources []*cbcli_cookbook.PlannedResource
+	)
+
+	if data, err = os.ReadFile(planFile); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to read launch plan '%s': %s", planFile, err.Error()))
+	}
+	if err = yaml.Unmarshal(data, &plan); err != nil || len(plan.TargetKey) == 0 {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("File '%s' is not a launch plan saved by 'cb target launch --plan-out'", planFile))
+	}
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(plan.TargetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				plan.TargetKey,
+			),
+		)
+	}
+	if tgt.Recipe.CookbookVersion() != plan.CookbookVersion {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"The cookbook has changed from version %s to %s since the plan was saved. Save a new plan to review the changes",
+				plan.CookbookVersion, tgt.Recipe.CookbookVersion(),
+			),
+		)
+	}
+	if inputHash, err = targetInputHash(tgt); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if inputHash != plan.InputHash {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"The configuration of target \"%s\" has changed since the plan was saved. Save a new plan to review the changes",
+				tgt.DeploymentName(),
+			),
+		)
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage(
+		"Applying launch plan for target \"%s\" saved at %s.",
+		tgt.DeploymentName(), plan.CreatedAt.Local().Format(time.RFC1123),
+	)
+	fmt.Println()
+
+	stdout := newPlanRecorder(os.Stdout)
+	if bldr, err = newTargetBuilder(tgt, stdout, os.Stderr, false); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if err = setRebuild(bldr, plan.Rebuild, plan.CleanRebuild); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	// the plan is computed again as the builder
+	// plans the launch when it is applied. if the
+	// backend state has changed the new plan will
+	// differ from the one that was reviewed.
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(" Verifying the launch plan has not changed."),
+		spinner.WithFinalMSG(""),
+		spinner.WithHiddenCursor(true),
+	)
+	s.Start()
+	stdout.start(false)
+	if err = bldr.ShowLaunchPlan(); err == nil {
+		resources, err = stdout.stop()
+	}
+	s.Stop()
+	if err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if planHash(stdout.output(), resources) != plan.PlanHash {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"The deployed resources or backend state of target \"%s\" have changed since the plan was saved. Save a new plan to review the changes",
+				tgt.DeploymentName(),
+			),
+		)
+	}
+
+	// the builder plans the launch again when it is
+	// applied so the plan in the launch output is
+	// compared with the plan that was reviewed
+	if resources, err = launchTargetResources(tgt, bldr, stdout); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if appliedPlanChanged(stdout, resources, plan.PlanHash) {
+		fmt.Println()
+		cbcli_utils.ShowErrorMessage(
+			fmt.Sprintf(
+				"The plan applied to target \"%s\" differed from the saved plan that was reviewed or could not be verified. Review the launch output above for the changes that were made.",
+				tgt.DeploymentName(),
+			),
+		)
+		cbcli_utils.ExitCode = 1
+	}
+	showNodeInfo(tgt)
+}
+
+// saves the launch plan of the target with the given
+// plan output and planned resources to a file
+func saveLaunchPlan(
+	planFile string,
+	tgt *target.Target,
+	output string,
+	resources []*cbcli_cookbook.PlannedResource,
+) error {
+
+	var (
+		err error
+
+		data []byte
+	)
+
+	plan := launchPlan{
+		TargetKey:       tgt.Key(),
+		CookbookVersion: tgt.Recipe.CookbookVersion(),
+		PlanHash:        planHash(output, resources),
+		Rebuild:         launchFlags.rebuild,
+		CleanRebuild:    launchFlags.cleanRebuild,
+		CreatedAt:       time.Now(),
+		Plan:            output,
+	}
+	if plan.InputHash, err = targetInputHash(tgt); err != nil {
+		return err
+	}
+	if data, err = yaml.Marshal(&plan); err != nil {
+		return err
+	}
+	// the plan output may contain
+	// configuration values
+	return os.WriteFile(planFile, data, 0600)
+}
+
+// returns a hash of the target's provider, recipe
+// and backend configuration values as well as the
+// targets it depends on
+func targetInputHash(tgt *target.Target) (string, error) {
+
+	var (
+		err error
+
+		inputForm forms.InputForm
+	)
+
+	h := sha256.New()
+	hashInputs := func(section string, inputForm forms.InputForm) {
+		for _, f := range inputForm.EnabledInputs(false) {
+			value := "<nil>"
+			if v := f.Value(); v != nil {
+				value = *v
+			}
+			writeHashField(h, section+"."+f.Name(), value)
+		}
+	}
+
+	if inputForm, err = tgt.Provider.InputForm(); err != nil {
+		return "", err
+	}
+	hashInputs("provider", inputForm)
+	if inputForm, err = tgt.Recipe.InputForm(); err != nil {
+		return "", err
+	}
+	hashInputs("recipe", inputForm)
+	if tgt.Backend != nil {
+		if inputForm, err = tgt.Backend.InputForm(); err != nil {
+			return "", err
+		}
+		hashInputs("backend", inputForm)
+	}
+	for _, dtgt := range tgt.Dependencies() {
+		writeHashField(h, "dependency", dtgt.Key())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
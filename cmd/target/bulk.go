@@ -0,0 +1,278 @@
+package target
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/color"
+
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/termtables"
+
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+const (
+	bulkFailed  = "failed"
+	bulkSkipped = "skipped"
+)
+
+// serializes updates to the target context
+// when targets are launched or deleted in
+// parallel
+var targetsMx sync.Mutex
+
+// a launch or delete of a target that runs once
+// all the tasks it depends on have succeeded
+type bulkTask struct {
+	tgt *target.Target
+
+	prereqs []*bulkTask
+	done    chan struct{}
+
+	result   string
+	err      error
+	duration time.Duration
+}
+
+func newBulkTask(tgt *target.Target) *bulkTask {
+	return &bulkTask{
+		tgt:  tgt,
+		done: make(chan struct{}),
+	}
+}
+
+// runs the given tasks with at most parallel tasks running
+// at a time and shows a summary of the results. the action
+// is run with writers that prefix the output of each target
+// and its result is recorded with the given success result.
+func runBulkTasks(
+	tasks []*bulkTask,
+	parallel int,
+	success string,
+	action func(tgt *target.Target, stdout, stderr io.Writer) error,
+) {
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	sem := make(chan struct{}, parallel)
+
+	outMx := &sync.Mutex{}
+	keyWidth := 0
+	for _, task := range tasks {
+		if len(task.tgt.Key()) > keyWidth {
+			keyWidth = len(task.tgt.Key())
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task *bulkTask) {
+			defer wg.Done()
+			defer close(task.done)
+
+			for _, prereq := range task.prereqs {
+				<-prereq.done
+				if prereq.result != success {
+					task.result = bulkSkipped
+					task.err = fmt.Errorf("target \"%s\" was not %s", prereq.tgt.DeploymentName(), success)
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			prefix := fmt.Sprintf("[%-*s] ", keyWidth, task.tgt.Key())
+			stdout := newPrefixWriter(os.Stdout, color.Cyan.Render(prefix), outMx)
+			stderr := newPrefixWriter(os.Stderr, color.Red.Render(prefix), outMx)
+
+			start := time.Now()
+			task.err = action(task.tgt, stdout, stderr)
+			task.duration = time.Since(start)
+			stdout.flush()
+			stderr.flush()
+
+			if task.err != nil {
+				task.result = bulkFailed
+			} else {
+				task.result = success
+			}
+		}(task)
+	}
+	wg.Wait()
+
+	showBulkSummary(tasks, success)
+}
+
+func showBulkSummary(tasks []*bulkTask, success string) {
+
+	failed := false
+
+	fmt.Println()
+	table := termtables.CreateTable()
+	table.AddHeaders(
+		color.OpBold.Render("Target"),
+		color.OpBold.Render("Result"),
+		color.OpBold.Render("Duration"),
+		color.OpBold.Render("Error"),
+	)
+	for _, task := range tasks {
+		var (
+			result, duration, errMsg string
+		)
+
+		switch task.result {
+		case success:
+			result = color.Green.Render(task.result)
+		case bulkFailed:
+			result = color.Red.Render(task.result)
+			failed = true
+		default:
+			result = color.Yellow.Render(task.result)
+			failed = true
+		}
+		if task.duration > 0 {
+			duration = task.duration.Round(time.Second).String()
+		}
+		if task.err != nil {
+			errMsg = task.err.Error()
+		}
+		table.AddRow(task.tgt.Key(), result, duration, errMsg)
+	}
+	fmt.Println(table.Render())
+
+	if failed {
+		cbcli_utils.ShowWarningMessage("\nSome targets were not %s.\n", success)
+		cbcli_utils.ExitCode = 1
+	} else {
+		cbcli_utils.ShowInfoMessage("\nAll targets were %s.\n", success)
+	}
+}
+
+// prefixWriter writes each line written to it
+// prefixed with the given prefix. lines of the
+// writers sharing a mutex are not interleaved.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+
+	mx   *sync.Mutex
+	line bytes.Buffer
+}
+
+func newPrefixWriter(out io.Writer, prefix string, mx *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		out:    out,
+		prefix: prefix,
+		mx:     mx,
+	}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+
+	for _, b := range p {
+		w.line.WriteByte(b)
+		if b == '\n' {
+			if err := w.writeLine(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// writes any remaining partial line
+func (w *prefixWriter) flush() {
+	if w.line.Len() > 0 {
+		w.line.WriteByte('\n')
+		_ = w.writeLine()
+	}
+}
+
+func (w *prefixWriter) writeLine() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	defer w.line.Reset()
+	_, err := fmt.Fprintf(w.out, "%s%s", w.prefix, w.line.String())
+	return err
+}
+
+// returns the configured targets that match a selector
+// of comma separated 'field=pattern' pairs where field
+// is one of 'recipe', 'cloud', 'region', 'name' or
+// 'type' and pattern is a glob pattern. an empty
+// selector matches all targets.
+func selectTargets(selector string) ([]*target.Target, error) {
+
+	type match struct {
+		field, pattern string
+	}
+	matches := []match{}
+
+	if selector = strings.TrimSpace(selector); len(selector) > 0 {
+		for _, term := range strings.Split(selector, ",") {
+			nv := strings.SplitN(term, "=", 2)
+			if len(nv) != 2 {
+				return nil, fmt.Errorf("invalid selector term '%s'. Expected 'field=pattern'", term)
+			}
+			m := match{
+				field:   strings.TrimSpace(nv[0]),
+				pattern: strings.TrimSpace(nv[1]),
+			}
+			switch m.field {
+			case "recipe", "cloud", "region", "name", "type":
+			default:
+				return nil, fmt.Errorf(
+					"invalid selector field '%s'. Expected one of 'recipe', 'cloud', 'region', 'name' or 'type'", m.field)
+			}
+			if _, err := path.Match(m.pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid selector pattern '%s': %s", m.pattern, err.Error())
+			}
+			matches = append(matches, m)
+		}
+	}
+
+	tgts := []*target.Target{}
+	for _, tgt := range configuredTargets() {
+		selected := true
+		for _, m := range matches {
+			var value string
+			switch m.field {
+			case "recipe":
+				value = tgt.RecipeName
+			case "cloud":
+				value = tgt.RecipeIaas
+			case "region":
+				if r := tgt.Provider.Region(); r != nil {
+					value = *r
+				}
+			case "name":
+				value = tgt.DeploymentName()
+			case "type":
+				if tgt.Recipe.IsBastion() {
+					value = "space"
+				} else {
+					value = "app"
+				}
+			}
+			if ok, _ := path.Match(m.pattern, value); !ok {
+				selected = false
+				break
+			}
+		}
+		if selected {
+			tgts = append(tgts, tgt)
+		}
+	}
+	return tgts, nil
+}
@@ -0,0 +1,245 @@
+package target
+
+import (
+	"fmt"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/appbricks/mycloudspace-client/api"
+	"github.com/appbricks/mycloudspace-client/mycscloud"
+	"github.com/mevansam/gocloud/provider"
+	"github.com/mevansam/goforms/config"
+	"github.com/mevansam/goforms/forms"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var cloneFlags = struct {
+	region string
+	cloud  string
+	name   string
+	space  string
+}{}
+
+var cloneCommand = &cobra.Command{
+	Use: "clone [source target key]",
+
+	Short: "Create a launch target by copying an existing target.",
+	Long: `
+Creates a new undeployed target with the recipe configuration of an
+existing target so that the same space or application can be set up
+in another region or cloud without re-entering its configuration. The
+source target is given by its key, which is listed by 'cb target
+list' (format <recipe>/<cloud>/<region>/<name>).
+
+The cloud provider configuration of the source target is copied when
+the target is cloned to the same cloud. When it is cloned to a
+different cloud the configuration of that cloud is used and recipe
+inputs that are not valid for it need to be configured with 'cb
+target configure' before the new target can be launched.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		CloneTarget(args[0])
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func CloneTarget(srcTargetKey string) {
+
+	var (
+		err error
+
+		srcTgt, tgt, spaceTgt *target.Target
+
+		srcProvider config.Configurable
+
+		srcRecipeInputForm,
+		recipeInputForm,
+		providerInputForm forms.InputForm
+
+		field *forms.InputField
+	)
+	config := cbcli_config.Config
+	context := config.TargetContext()
+
+	if srcTgt, err = context.GetTarget(srcTargetKey); err != nil || srcTgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				srcTargetKey,
+			),
+		)
+	}
+	iaasName := cloneFlags.cloud
+	if len(iaasName) == 0 {
+		iaasName = srcTgt.RecipeIaas
+	}
+
+	if tgt, err = context.NewTarget(srcTgt.RecipeName, iaasName); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Recipe \"%s\" of target \"%s\" cannot be launched to cloud \"%s\". "+
+					"Run 'cb recipe list' to get list of available recipes.",
+				srcTgt.RecipeName, srcTgt.DeploymentName(), iaasName,
+			),
+		)
+	}
+	if _, err = tgt.UpdateKeys(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	// reuse the source target's provider configuration if
+	// the target is cloned to the same cloud otherwise the
+	// configuration of the cloud is used
+	if iaasName == srcTgt.RecipeIaas {
+		if srcProvider, err = srcTgt.Provider.Copy(); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		tgt.Provider = srcProvider.(provider.CloudProvider)
+	}
+	if !tgt.Provider.IsValid() {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Credentials for the '%s' cloud provider have not been configured. "+
+					"Run 'cb cloud configure %s' to configure the cloud provider.",
+				iaasName, iaasName,
+			),
+		)
+	}
+	if providerInputForm, err = tgt.Provider.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if field, err = providerInputForm.GetInputField("region"); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("The '%s' cloud provider does not have a region to clone the target to.", iaasName))
+	}
+	if err = field.SetValue(&cloneFlags.region); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Invalid region '%s': %s", cloneFlags.region, err.Error()))
+	}
+
+	// copy the source target's recipe inputs
+	if srcRecipeInputForm, err = srcTgt.Recipe.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if recipeInputForm, err = tgt.Recipe.InputForm(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	for _, srcField := range srcRecipeInputForm.EnabledInputs(false, "recipe", "target-undeployed") {
+		if srcField.Name() == "region" || srcField.Value() == nil {
+			continue
+		}
+		if field, err = recipeInputForm.GetInputField(srcField.Name()); err != nil {
+			continue
+		}
+		if err = field.SetValue(srcField.Value()); err != nil {
+			cbcli_utils.ShowWarningMessage(
+				"Recipe input '%s' was not copied as its value is not valid for the new target: %s",
+				srcField.Name(), err.Error(),
+			)
+		}
+	}
+	if len(cloneFlags.name) > 0 {
+		if field, err = recipeInputForm.GetInputField("name"); err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Recipe '%s' does not have a deployment name input.", tgt.RecipeName))
+		}
+		if err = field.SetValue(&cloneFlags.name); err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Invalid deployment name '%s': %s", cloneFlags.name, err.Error()))
+		}
+	}
+	// set the target's recipe region variable
+	// to be same value as that of the provider
+	if field, err = recipeInputForm.GetInputField("region"); err == nil {
+		logger.TraceMessage(
+			"Setting the recipe '%s' region value to: %s",
+			tgt.RecipeName, cloneFlags.region,
+		)
+		if err = field.SetValue(&cloneFlags.region); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	// applications are attached to the
+	// same space as the source target
+	// unless another space is given
+	if !tgt.Recipe.IsBastion() {
+		spaceTgtKey := cloneFlags.space
+		if len(spaceTgtKey) == 0 && len(srcTgt.DependentTargets) > 0 {
+			spaceTgtKey = srcTgt.DependentTargets[0]
+		}
+		if spaceTgt = context.TargetSet().GetTarget(spaceTgtKey); spaceTgt == nil || !spaceTgt.Recipe.IsBastion() {
+			cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Invalid space target key '%s'.", spaceTgtKey))
+		}
+		tgt.DependentTargets = []string{spaceTgtKey}
+	}
+
+	if context.HasTarget(tgt.Key()) {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" already exists. Provide a different region or name with the '--region' or '--name' options.",
+				tgt.Key(),
+			),
+		)
+	}
+
+	// configure the target's backend for
+	// the new deployment name and region
+	if tgt.Backend != nil && !tgt.Backend.IsValid() {
+		if err = tgt.Backend.Configure(
+			tgt.Provider,
+			tgt.DeploymentName(), tgt.RecipeName,
+		); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+	context.SaveTarget(tgt.Key(), tgt)
+
+	// add target to MyCS account
+	if tgt.Recipe.IsBastion() {
+		spaceAPI := mycscloud.NewSpaceAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if err = spaceAPI.AddSpace(tgt, true); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	} else {
+		appAPI := mycscloud.NewAppAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if err = appAPI.AddApp(tgt, spaceTgt.GetSpaceID()); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	fmt.Println()
+	fmt.Print(color.Green.Render(fmt.Sprintf("Target \"%s\" has been cloned to \"%s\".\n", srcTgt.Key(), tgt.Key())))
+	if !tgt.Recipe.IsValid() {
+		cbcli_utils.ShowWarningMessage(
+			"\nThe configuration of the new target is not complete. Run 'cb target configure' to complete it before launching the target.")
+	}
+	fmt.Println()
+}
+
+func init() {
+	flags := cloneCommand.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&cloneFlags.region, "region", "r", "",
+		"region to clone the target to")
+	flags.StringVarP(&cloneFlags.cloud, "cloud", "c", "",
+		"cloud to clone the target to (defaults to the source target's cloud)")
+	flags.StringVarP(&cloneFlags.name, "name", "n", "",
+		"deployment name of the new target (defaults to the source target's name)")
+	flags.StringVarP(&cloneFlags.space, "space", "s", "",
+		"space target key to attach a cloned application to\n(format <recipe>/<cloud>/<region>/<name>)")
+	cloneCommand.MarkFlagRequired("region")
+}
@@ -22,7 +22,8 @@ import (
 var configureFlags = struct {
 	commonFlags
 
-	all bool
+	all    bool
+	values string
 }{}
 
 var configureCommand = &cobra.Command{
@@ -50,21 +51,22 @@ func ConfigureTarget(targetKey string) {
 
 		tgt *target.Target
 	)
+	values := loadTargetValues(configureFlags.values)
 
 	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err == nil && tgt != nil {
 
 		if tgt.Status() == target.Undeployed {
 			if configureFlags.all {
-				configureTarget(tgt, "recipe", "target-undeployed")
+				configureTarget(tgt, values, false, "recipe", "target-undeployed")
 			} else {
-				configureTarget(tgt, "target-undeployed")
+				configureTarget(tgt, values, false, "target-undeployed")
 			}
 		} else {
 			if configureFlags.all {
 				cbcli_utils.ShowErrorAndExit("You can re-configure all inputs for undeployed targets only.")
 			}
 
-			configureTarget(tgt, "target-deployed")
+			configureTarget(tgt, values, false, "target-deployed")
 		}
 		return
 	}
@@ -77,62 +79,79 @@ func ConfigureTarget(targetKey string) {
 	)
 }
 
-func configureTarget(tgt *target.Target, tags ...string) {
+// configures the target's provider, recipe and backend
+// interactively or from the given values. a new target
+// only replaces an existing target with the same key if
+// confirmed.
+func configureTarget(tgt *target.Target, values *targetValues, newTarget bool, tags ...string) {
 
 	var (
 		err error
 
 		recipeInputForm,
 		backendInputForm forms.InputForm
-
-		response string
 	)
 
 	targetKey := tgt.Key()
 
-	// target form title header
-	divider := strings.Repeat("+", 80)
-	fmt.Println()
-	fmt.Println(divider)
-	fmt.Println()
-	fmt.Println(
-		color.OpBold.Render(
-			utils.FormatMessage(
-				0, 80, true, false,
-				"Configure Target %s",
-				tgt.Name(),
+	if values == nil {
+		// target form title header
+		divider := strings.Repeat("+", 80)
+		fmt.Println()
+		fmt.Println(divider)
+		fmt.Println()
+		fmt.Println(
+			color.OpBold.Render(
+				utils.FormatMessage(
+					0, 80, true, false,
+					"Configure Target %s",
+					tgt.Name(),
+				),
 			),
-		),
-	)
-	fmt.Println()
-	fmt.Println(divider)
-	fmt.Println()
+		)
+		fmt.Println()
+		fmt.Println(divider)
+		fmt.Println()
+	}
 
 	// reconfigure existing target's recipe variables
 	if recipeInputForm, err = tgt.Recipe.InputForm(); err != nil {
 		cbcli_utils.ShowErrorAndExit(err.Error())
 	}
-	if err = ux.GetFormInput(recipeInputForm,
-		fmt.Sprintf(
-			"Target's Recipe \"%s\" Configuration",
-			tgt.Recipe.Name(),
-		),
-		"CONFIGURATION DATA INPUT",
-		2, 80, tags...,
-	); err != nil {
-		cbcli_utils.ShowErrorAndExit(err.Error())
+	if values != nil {
+		values.apply("recipe", recipeInputForm, values.Recipe, tags...)
+
+	} else {
+		cbcli_utils.AssertInteractive("recipe-configuration", "configure target's recipe")
+		if err = ux.GetFormInput(recipeInputForm,
+			fmt.Sprintf(
+				"Target's Recipe \"%s\" Configuration",
+				tgt.Recipe.Name(),
+			),
+			"CONFIGURATION DATA INPUT",
+			2, 80, tags...,
+		); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
 	}
 
 	// ensure target's recipe configuration is complete before proceeding
 	if !tgt.Recipe.IsValid() {
-		cbcli_utils.ShowErrorAndExit(
-			fmt.Sprintf(
-				"Configuration values for the recipe '%s' are not complete. "+
-					"Run 'cb recipe configure %s %s' to ensure common recipe "+
-					"configurations have been saved",
-				tgt.RecipeName, tgt.RecipeName, tgt.RecipeIaas,
-			),
-		)
+		if values != nil {
+			values.addError(
+				"recipe: configuration values for the recipe '%s' are not complete",
+				tgt.RecipeName,
+			)
+		} else {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Configuration values for the recipe '%s' are not complete. "+
+						"Run 'cb recipe configure %s %s' to ensure common recipe "+
+						"configurations have been saved",
+					tgt.RecipeName, tgt.RecipeName, tgt.RecipeIaas,
+				),
+			)
+		}
 	}
 
 	// configure the recipe backend
@@ -151,36 +170,59 @@ func configureTarget(tgt *target.Target, tags ...string) {
 				}
 			}
 	
-			if err = ux.GetFormInput(backendInputForm,
-				fmt.Sprintf(
-					"Target's Backend \"%s\" Configuration",
-					tgt.Backend.Name(),
-				),
-				"CONFIGURATION DATA INPUT",
-				2, 80, "target-undeployed",
-			); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
+			if values != nil {
+				values.apply("backend", backendInputForm, values.Backend, "target-undeployed")
+				
+			} else {
+				cbcli_utils.AssertInteractive("backend-configuration", "configure target's backend")
+				if err = ux.GetFormInput(backendInputForm,
+					fmt.Sprintf(
+						"Target's Backend \"%s\" Configuration",
+						tgt.Backend.Name(),
+					),
+					"CONFIGURATION DATA INPUT",
+					2, 80, "target-undeployed",
+				); err != nil {
+					cbcli_utils.ShowErrorAndExit(err.Error())
+				}
 			}
-		}	
+		} else if values != nil && len(values.Backend) > 0 {
+			values.addError("backend: the target's backend cannot be configured")
+		}
+	} else if values != nil && len(values.Backend) > 0 {
+		values.addError("backend: the target's recipe does not have a backend")
 	}
 
-	// save target
-	if cbcli_config.Config.TargetContext().HasTarget(tgt.Key()) {
+	if values != nil {
+		// report all missing or invalid values
+		// and save the target without prompting
+		values.assertValid()
+	}
+	if (values == nil || newTarget) && cbcli_config.Config.TargetContext().HasTarget(tgt.Key()) {
 
+		if values != nil && !cbcli_utils.NonInteractive {
+			// values are applied without prompting so an existing
+			// target is only replaced by a new target if explicitly
+			// confirmed
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Target \"%s\" exists. Provide the '--yes' option or an 'overwrite-target' answer to overwrite it.",
+					tgt.Key(),
+				),
+			)
+		}
 		fmt.Print(utils.FormatMessage(7, 80, false, true, tgt.Name()))
 		fmt.Println(" exists.")
-		response = cbcli_utils.GetUserInputFromList(
+		if !cbcli_utils.GetYesNoUserInput(
+			"overwrite-target",
 			"Do you wish to overwrite it (yes/no)? ",
-			"yes",
-			[]string{"no", "yes"},
 			true,
-		)
-
-		if len(response) > 0 && response != "yes" {
+		) {
 			fmt.Print(color.Red.Render("\nConfiguration for target was not saved.\n\n"))
 			return
 		}
 	}
+	// save target
 	cbcli_config.Config.TargetContext().SaveTarget(targetKey, tgt)
 	fmt.Print(color.Green.Render("\nConfiguration for target saved.\n\n"))
 }
@@ -192,4 +234,6 @@ func init() {
 
 	flags.BoolVarP(&configureFlags.all, "all", "a", false, 
 		"configure all possible configuration data values")
+	flags.StringVarP(&configureFlags.values, "values", "f", "", 
+		"yaml or json file with the target's configuration values\n(the target is saved without prompting for input)")
 }
@@ -80,7 +80,7 @@ func ConnectTarget(targetKey string) {
 	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err == nil && tgt != nil {
 
 		if tgt.GetStatus() != "running" {
-			ResumeTarget(targetKey)
+			ResumeTarget(targetKey, nil)
 		}
 
 		// create api client for target node
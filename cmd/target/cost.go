@@ -0,0 +1,303 @@
+package target
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/termtables"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var costFlags = struct {
+	commonFlags
+
+	importCatalogue string
+}{}
+
+var costCommand = &cobra.Command{
+	Use: "cost [recipe] [cloud] [deployment name]",
+
+	Short: "Estimate the monthly cost of a target.",
+	Long: `
+Estimates the monthly cost of the cloud resources a target's launch
+plan creates or changes and the total monthly cost of the target once
+the plan is applied. For a target that has not been deployed this is
+the cost of all of its resources. For a deployed target the estimate
+shows how the target's cost will change when it is re-launched. The
+resources the plan does not change are priced from the resources
+recorded when the target was launched by the CLI. If these are not
+known, as for targets last launched by an earlier version of the CLI,
+they are reported as not priced.
+
+Resources are priced using a catalogue of instance, disk and public
+IP prices per cloud region that is bundled with the CLI. As prices
+change over time a more recent catalogue can be imported without
+network access by providing the '--import-catalogue' option. An
+imported catalogue is used until the CLI is upgraded with a bundled
+catalogue that is more recent.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(costFlags.importCatalogue) > 0 {
+			ImportPricingCatalogue(costFlags.importCatalogue)
+		} else {
+			ShowTargetCost(getTargetKeyFromArgs(args[0], args[1], args[2], &(costFlags.commonFlags)))
+		}
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(costFlags.importCatalogue) > 0 {
+			return cobra.ExactArgs(0)(cmd, args)
+		}
+		return cobra.ExactArgs(3)(cmd, args)
+	},
+}
+
+func ShowTargetCost(targetKey string) {
+
+	var (
+		err error
+
+		tgt  *target.Target
+		bldr *target.Builder
+
+		resources []*cbcli_cookbook.PlannedResource
+		estimate  *cbcli_cookbook.CostEstimate
+	)
+	context := cbcli_config.Config.TargetContext()
+
+	if tgt, err = context.GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(" Computing the launch plan of the target."),
+		spinner.WithFinalMSG(""),
+		spinner.WithHiddenCursor(true),
+	)
+	if cbcli_utils.IsTableOutput() {
+		s.Start()
+	}
+
+	// the builder's output is only used to
+	// determine the resources in the plan
+	stdout := newPlanRecorder(io.Discard)
+	if bldr, err = newTargetBuilder(tgt, stdout, os.Stderr, false); err == nil {
+		stdout.start(false)
+		if err = bldr.ShowLaunchPlan(); err == nil {
+			tgt.CookbookVersion = tgt.Recipe.CookbookVersion()
+			context.SaveTarget(tgt.Key(), tgt)
+
+			if resources, err = stdout.stop(); err == nil {
+				estimate, err = estimateCost(tgt, resources)
+			}
+		}
+	}
+	s.Stop()
+	if err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	if !cbcli_utils.IsTableOutput() {
+		cbcli_utils.RenderOutput(estimate)
+		return
+	}
+	fmt.Println()
+	showCostEstimate(estimate)
+}
+
+func ImportPricingCatalogue(path string) {
+
+	var (
+		err error
+
+		catalogue *cbcli_cookbook.PricingCatalogue
+	)
+
+	if catalogue, err = cbcli_cookbook.ImportPricingCatalogue(path); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to import pricing catalogue '%s': %s", path, err.Error()))
+	}
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage(
+		"The pricing catalogue with %s prices updated on %s has been imported.",
+		catalogue.Currency, catalogue.Updated,
+	)
+	fmt.Println()
+}
+
+// estimates the monthly cost of the given resources
+// in a launch plan of the target and the total cost of
+// the target once the plan is applied. if the target
+// has been deployed but the resources it deployed are
+// not known they are reported as unpriced.
+func estimateCost(
+	tgt *target.Target,
+	resources []*cbcli_cookbook.PlannedResource,
+) (*cbcli_cookbook.CostEstimate, error) {
+
+	var (
+		err error
+
+		catalogue *cbcli_cookbook.PricingCatalogue
+		region    string
+		settings  *cbcli_config.TargetSettings
+		deployed  []*cbcli_cookbook.PlannedResource
+	)
+
+	if catalogue, err = cbcli_cookbook.LoadPricingCatalogue(); err != nil {
+		return nil, err
+	}
+	if r := tgt.Provider.Region(); r != nil {
+		region = *r
+	}
+
+	known := true
+	if tgt.Status() != target.Undeployed {
+		if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil {
+			return nil, err
+		}
+		if known = settings.Deployed != nil; known {
+			for address, resource := range settings.Deployed.Resources {
+				deployed = append(deployed, &cbcli_cookbook.PlannedResource{
+					Address: address,
+					Type:    resource.Type,
+					After:   resource.Attributes,
+				})
+			}
+		}
+	}
+
+	estimate := catalogue.Estimate(tgt.RecipeIaas, region, deployed, resources)
+	if !known {
+		estimate.Unpriced = append(estimate.Unpriced,
+			"resources of the deployed target not changed by the plan (not recorded as the target was not launched by this version of the CLI)")
+	}
+	return estimate, nil
+}
+
+// records the resources deployed by the target once
+// a launch has applied the given plan. the resources
+// are only known if they were known before the launch
+// or the target had not been deployed. if the applied
+// plan is not known the deployed resources are cleared.
+func recordDeployedResources(
+	tgt *target.Target,
+	wasDeployed bool,
+	applied []*cbcli_cookbook.PlannedResource,
+) error {
+
+	return cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+		if !wasDeployed {
+			settings.Deployed = &cbcli_config.DeployedResources{}
+		}
+		if applied == nil || settings.Deployed == nil {
+			settings.Deployed = nil
+			return nil
+		}
+		if settings.Deployed.Resources == nil {
+			settings.Deployed.Resources = make(map[string]*cbcli_config.DeployedResource)
+		}
+		for _, resource := range applied {
+			deployed, exists := settings.Deployed.Resources[resource.Address]
+
+			switch {
+			case resource.Action == cbcli_cookbook.PlanDestroy:
+				delete(settings.Deployed.Resources, resource.Address)
+
+			case resource.Action == cbcli_cookbook.PlanUpdate && exists:
+				// the plan only shows the attributes
+				// of an update that are changed
+				if deployed.Attributes == nil {
+					deployed.Attributes = make(map[string]string)
+				}
+				for name, value := range resource.After {
+					deployed.Attributes[name] = value
+				}
+
+			default:
+				attributes := make(map[string]string)
+				for name, value := range resource.After {
+					attributes[name] = value
+				}
+				settings.Deployed.Resources[resource.Address] = &cbcli_config.DeployedResource{
+					Type:       resource.Type,
+					Attributes: attributes,
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func showCostEstimate(estimate *cbcli_cookbook.CostEstimate) {
+
+	fmt.Println(color.OpBold.Render("\nEstimated Monthly Cost\n======================\n"))
+
+	if len(estimate.Items) > 0 {
+		table := termtables.CreateTable()
+		table.AddHeaders(
+			color.OpBold.Render("Resource"),
+			color.OpBold.Render("Action"),
+			color.OpBold.Render("Priced As"),
+			color.OpBold.Render("Monthly"),
+			color.OpBold.Render("Change"),
+		)
+		for _, item := range estimate.Items {
+			table.AddRow(
+				item.Address,
+				item.Action,
+				item.Description,
+				fmt.Sprintf("%.2f", item.Monthly),
+				fmt.Sprintf("%+.2f", item.Change),
+			)
+		}
+		fmt.Println(table.Render())
+	} else {
+		fmt.Println("The launch plan does not create or change any priced resources.")
+	}
+
+	fmt.Printf(
+		"\nLaunch plan: %.2f %s per month (%+.2f %s).\nTotal: %.2f %s per month once the plan is applied. Prices as of %s.\n",
+		estimate.Monthly, estimate.Currency,
+		estimate.Change, estimate.Currency,
+		estimate.Total, estimate.Currency,
+		estimate.PricesUpdated,
+	)
+	if len(estimate.Unpriced) > 0 {
+		cbcli_utils.ShowWarningMessage("\nThe following resources could not be priced and are not included in the estimate.")
+		for _, unpriced := range estimate.Unpriced {
+			fmt.Printf("  - %s\n", unpriced)
+		}
+	}
+	fmt.Println()
+}
+
+func init() {
+	flags := costCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(costFlags.commonFlags))
+
+	flags.StringVar(&costFlags.importCatalogue, "import-catalogue", "",
+		"import a pricing catalogue file used to estimate costs")
+}
@@ -0,0 +1,436 @@
+package target
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/gocloud/cloud"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var cpFlags = struct {
+	commonFlags
+
+	recursive bool
+	sudo      bool
+}{}
+
+var cpCommand = &cobra.Command{
+	Use: "cp [recipe] [cloud] [deployment name] [source] [destination]",
+
+	Short: "Copy files to and from a launch target's instances.",
+	Long: `
+Copies files between the local file system and one of the target's
+running instances over SFTP. One of the source or destination must be
+a path on an instance given as '<instance>:<path>'. The instance name
+may be omitted, i.e. ':<path>', if the target has only one instance.
+For example:
+
+  cb target cp sandbox aws MyVPN -r us-east-1 bastion:/var/log/syslog .
+
+Directories are copied with the '-R|--recursive' option. Files copied
+to an instance are owned by the instance's SSH user. To copy files to
+paths only writable by root provide the '--sudo' option which stages
+the files in a temporary directory and moves them into place as root.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		CopyTargetFiles(
+			getTargetKeyFromArgs(args[0], args[1], args[2], &(cpFlags.commonFlags)),
+			args[3], args[4],
+		)
+	},
+	Args: cobra.ExactArgs(5),
+}
+
+func CopyTargetFiles(targetKey, src, dst string) {
+
+	var (
+		err error
+
+		tgt       *target.Target
+		instances []*target.ManagedInstance
+		state     cloud.InstanceState
+
+		client     *instanceConnection
+		sftpClient *sftp.Client
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+
+	srcInstance, srcPath, srcRemote := parseCopyPath(src)
+	dstInstance, dstPath, dstRemote := parseCopyPath(dst)
+	if srcRemote == dstRemote {
+		cbcli_utils.ShowErrorAndExit(
+			"One of the source or destination must be a path on an instance given as '<instance>:<path>'.")
+	}
+	upload := dstRemote
+	if cpFlags.sudo && !upload {
+		cbcli_utils.ShowErrorAndExit("The '--sudo' option can only be used when copying files to an instance.")
+	}
+	instanceName := srcInstance
+	if upload {
+		instanceName = dstInstance
+	}
+
+	if len(instanceName) > 0 {
+		if instances, err = selectManagedInstances(tgt, []string{instanceName}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	} else if instances = tgt.ManagedInstances(); len(instances) > 1 {
+		cbcli_utils.ShowErrorAndExit(
+			"Target is running more than one managed instance. Provide the instance to copy files to or from as '<instance>:<path>'.")
+	}
+	if len(instances) == 0 {
+		cbcli_utils.ShowErrorAndExit("No managed instances have been deployed.")
+	}
+	instance := instances[0]
+
+	if state, err = instance.State(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if state != cloud.StateRunning {
+		cbcli_utils.ShowErrorAndExit("instance is not running")
+	}
+	if client, err = dialManagedInstance(tgt, instance); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	defer client.Close()
+
+	if sftpClient, err = sftp.NewClient(client.Client); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to start an SFTP session with instance \"%s\": %s", instance.Name(), err.Error()))
+	}
+	defer sftpClient.Close()
+
+	c := &fileCopier{
+		sftp:     sftpClient,
+		progress: term.IsTerminal(int(os.Stderr.Fd())),
+	}
+	switch {
+	case upload && cpFlags.sudo:
+		err = c.uploadWithSudo(client.Client, instance, srcPath, dstPath)
+	case upload:
+		err = c.upload(srcPath, dstPath)
+	default:
+		err = c.download(srcPath, dstPath)
+	}
+	if err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+}
+
+// parses a copy path of the form '<instance>:<path>'
+// returning the instance name, the path and whether
+// the path is on an instance
+func parseCopyPath(p string) (string, string, bool) {
+	i := strings.Index(p, ":")
+	if i < 0 || strings.ContainsAny(p[:i], `/\`) || (i == 1 && filepath.VolumeName(p) != "") {
+		return "", p, false
+	}
+	return p[:i], p[i+1:], true
+}
+
+// copies files between the local file
+// system and an instance via SFTP
+type fileCopier struct {
+	sftp     *sftp.Client
+	progress bool
+}
+
+// copies a local file or directory to the remote path. if
+// the remote path is an existing directory the source is
+// copied into it.
+func (c *fileCopier) upload(src, dst string) error {
+
+	var (
+		err error
+
+		srcInfo, dstInfo os.FileInfo
+	)
+
+	if srcInfo, err = os.Stat(src); err != nil {
+		return err
+	}
+	if srcInfo.IsDir() && !cpFlags.recursive {
+		return fmt.Errorf("'%s' is a directory. Provide the '--recursive' option to copy directories", src)
+	}
+	if dstInfo, err = c.sftp.Stat(dst); err == nil && dstInfo.IsDir() {
+		dst = path.Join(dst, filepath.Base(src))
+	}
+
+	return filepath.Walk(src, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, localPath)
+		if err != nil {
+			return err
+		}
+		remotePath := path.Join(dst, filepath.ToSlash(rel))
+
+		if info.IsDir() {
+			if err = c.sftp.MkdirAll(remotePath); err != nil {
+				return fmt.Errorf("unable to create remote directory '%s': %s", remotePath, err.Error())
+			}
+			return c.sftp.Chmod(remotePath, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := c.sftp.OpenFile(remotePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return fmt.Errorf("unable to create remote file '%s': %s", remotePath, err.Error())
+		}
+		if err = c.copy(out, in, rel, info.Size()); err != nil {
+			out.Close()
+			return err
+		}
+		if err = out.Chmod(info.Mode().Perm()); err != nil {
+			out.Close()
+			return err
+		}
+		// the remote file may not have been
+		// completely written until it is closed
+		if err = out.Close(); err != nil {
+			return fmt.Errorf("unable to write remote file '%s': %s", remotePath, err.Error())
+		}
+		return nil
+	})
+}
+
+// copies a remote file or directory to the local path. if
+// the local path is an existing directory the source is
+// copied into it.
+func (c *fileCopier) download(src, dst string) error {
+
+	var (
+		err error
+
+		srcInfo, dstInfo os.FileInfo
+	)
+
+	if srcInfo, err = c.sftp.Stat(src); err != nil {
+		return fmt.Errorf("unable to access remote path '%s': %s", src, err.Error())
+	}
+	if srcInfo.IsDir() && !cpFlags.recursive {
+		return fmt.Errorf("'%s' is a directory. Provide the '--recursive' option to copy directories", src)
+	}
+	if dstInfo, err = os.Stat(dst); err == nil && dstInfo.IsDir() {
+		dst = filepath.Join(dst, path.Base(src))
+	}
+
+	walker := c.sftp.Walk(src)
+	for walker.Step() {
+		if err = walker.Err(); err != nil {
+			return err
+		}
+		remotePath, info := walker.Path(), walker.Stat()
+		rel := strings.TrimPrefix(strings.TrimPrefix(remotePath, src), "/")
+		localPath := filepath.Join(dst, filepath.FromSlash(rel))
+
+		if info.IsDir() {
+			if err = os.MkdirAll(localPath, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err = c.downloadFile(remotePath, localPath, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fileCopier) downloadFile(remotePath, localPath string, info os.FileInfo) error {
+
+	in, err := c.sftp.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("unable to open remote file '%s': %s", remotePath, err.Error())
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if err = c.copy(out, in, path.Base(remotePath), info.Size()); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copies the local source to a temporary directory on the
+// instance and moves it to the destination as root using
+// the instance's root password if it has one
+func (c *fileCopier) uploadWithSudo(
+	client *ssh.Client,
+	instance *target.ManagedInstance,
+	src, dst string,
+) error {
+
+	var (
+		err error
+
+		tmpDir string
+	)
+
+	if tmpDir, err = c.sftp.RealPath(fmt.Sprintf(".cb-cp-%d", time.Now().UnixNano())); err != nil {
+		return err
+	}
+	if err = c.sftp.Mkdir(tmpDir); err != nil {
+		return fmt.Errorf("unable to create staging directory '%s': %s", tmpDir, err.Error())
+	}
+	defer runSudo(client, instance, "rm -rf "+shellQuote(tmpDir))
+
+	staged := path.Join(tmpDir, filepath.Base(src))
+	if err = c.upload(src, staged); err != nil {
+		return err
+	}
+	// copy into a destination directory as 'cp' does and keep
+	// the ownership of files that are being replaced
+	script := fmt.Sprintf(
+		`if [ -d %[2]s ]; then cp -R %[1]s %[2]s/; else cp -R %[1]s %[2]s; fi`,
+		shellQuote(staged), shellQuote(dst),
+	)
+	if output, err := runSudo(client, instance, script); err != nil {
+		return fmt.Errorf("unable to copy files to '%s' as root: %s %s", dst, err.Error(), strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// copies the source to the destination showing
+// the progress of the copy if enabled
+func (c *fileCopier) copy(dst io.Writer, src io.Reader, name string, size int64) error {
+
+	p := &copyProgress{
+		name:  name,
+		size:  size,
+		start: time.Now(),
+		show:  c.progress,
+	}
+	_, err := io.Copy(dst, io.TeeReader(src, p))
+	p.done(err == nil)
+	return err
+}
+
+// copyProgress shows the progress of
+// a file copy on a single line
+type copyProgress struct {
+	name  string
+	size  int64
+	start time.Time
+	show  bool
+
+	copied int64
+	shown  time.Time
+}
+
+func (p *copyProgress) Write(b []byte) (int, error) {
+	p.copied += int64(len(b))
+	if p.show && time.Since(p.shown) > 100*time.Millisecond {
+		p.shown = time.Now()
+		p.print()
+	}
+	return len(b), nil
+}
+
+func (p *copyProgress) print() {
+	percent := 100
+	if p.size > 0 {
+		percent = int(p.copied * 100 / p.size)
+	}
+	rate := float64(p.copied) / time.Since(p.start).Seconds()
+	fmt.Fprintf(os.Stderr, "\r\033[K%s  %3d%%  %s  %s/s",
+		p.name, percent, formatBytes(float64(p.copied)), formatBytes(rate))
+}
+
+func (p *copyProgress) done(ok bool) {
+	if p.show {
+		p.print()
+	} else if ok {
+		fmt.Fprintf(os.Stderr, "%s  %s", p.name, formatBytes(float64(p.copied)))
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB"}
+	i := 0
+	for ; n >= 1024 && i < len(units)-1; i++ {
+		n /= 1024
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
+
+// runs a shell command as root on the instance. the root
+// password is sent to sudo via stdin if the instance
+// has one.
+func runSudo(client *ssh.Client, instance *target.ManagedInstance, command string) (string, error) {
+
+	var (
+		err error
+
+		session *ssh.Session
+		output  []byte
+	)
+
+	if session, err = client.NewSession(); err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	sudo := "sudo -n"
+	if password := instance.RootPassword(); len(password) > 0 {
+		sudo = "sudo -S -p ''"
+		session.Stdin = strings.NewReader(password + "\n")
+	}
+	output, err = session.CombinedOutput(sudo + " sh -c " + shellQuote(command))
+	return string(output), err
+}
+
+func init() {
+	flags := cpCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(cpFlags.commonFlags))
+
+	flags.BoolVarP(&cpFlags.recursive, "recursive", "R", false,
+		"copy directories recursively")
+	flags.BoolVarP(&cpFlags.sudo, "sudo", "u", false,
+		"copy files to the instance as root")
+}
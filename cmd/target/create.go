@@ -22,6 +22,7 @@ import (
 
 var createFlags = struct {
 	dependentTarget string
+	values          string
 }{}
 
 var createCommand = &cobra.Command{
@@ -62,6 +63,11 @@ func CreateTarget(recipeKey, iaasName string) {
 	config := cbcli_config.Config
 	context := config.TargetContext()
 
+	values := loadTargetValues(createFlags.values)
+	if values != nil && len(createFlags.dependentTarget) == 0 {
+		createFlags.dependentTarget = values.Space
+	}
+
 	if tgt, err = context.NewTarget(
 		recipeKey, iaasName,
 	); err == nil && tgt != nil {
@@ -111,6 +117,7 @@ func CreateTarget(recipeKey, iaasName string) {
 					os.Exit(0)
 				}
 				spaceTgtKey = cbcli_utils.GetUserInputFromList(
+					"space-target",
 					"User <TAB> to scroll through and select from the list of target spaces to deploy application to: ",
 					spaceTargets[0],
 					spaceTargets,
@@ -128,22 +135,33 @@ func CreateTarget(recipeKey, iaasName string) {
 				// if iaas' match then the application target can reuse the
 				// same provider configuration as that of the space to which 
 				// it will be deployed to
-				copySpaceTgtProvider = cbcli_utils.GetYesNoUserInput(
-					"Do you wish deploy to the same cloud environment as the space node : ", 
-					false,
-				)
+				if values != nil {
+					// reuse the space's provider configuration
+					// if one was not provided in the values file
+					copySpaceTgtProvider = len(values.Provider) == 0
+				} else {
+					copySpaceTgtProvider = cbcli_utils.GetYesNoUserInput(
+						"use-space-cloud-environment",
+						"Do you wish deploy to the same cloud environment as the space node : ", 
+						false,
+					)
+				}
 				if copySpaceTgtProvider {
 					if spaceTgtProvider, err = spaceTgt.Provider.Copy(); err != nil {
 						cbcli_utils.ShowErrorAndExit(err.Error())
 					}
 					tgt.Provider = spaceTgtProvider.(provider.CloudProvider)
 				}
-				configureProvider = false
+				configureProvider = values != nil && !copySpaceTgtProvider
 			}
 		}
 
 		// configure the target recipe's provider if required
-		if configureProvider {
+		if configureProvider && values != nil {
+			values.apply("provider", providerInputForm, values.Provider, "target-undeployed")
+
+		} else if configureProvider {
+			cbcli_utils.AssertInteractive("provider-configuration", "configure cloud provider for new target")
 			if err = ux.GetFormInput(providerInputForm,
 				fmt.Sprintf(
 					"Configure Cloud Provider \"%s\" for New Target",
@@ -179,7 +197,7 @@ func CreateTarget(recipeKey, iaasName string) {
 			)
 		}
 
-		configureTarget(tgt, "target-undeployed")
+		configureTarget(tgt, values, true, "target-undeployed")
 
 		// add target to MyCS account
 		if tgt.Recipe.IsBastion() {
@@ -217,4 +235,6 @@ func init() {
 	flags.SortFlags = false
 	flags.StringVarP(&createFlags.dependentTarget, "space", "s", "", 
 		"space target key to deploy application to (format <recipe>/<cloud>/<region>/<name>)")
+	flags.StringVarP(&createFlags.values, "values", "f", "", 
+		"yaml or json file with the target's configuration values\n(the target is saved without prompting for input)")
 }
@@ -2,6 +2,7 @@ package target
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/gookit/color"
@@ -22,8 +23,10 @@ import (
 var deleteFlags = struct {
 	commonFlags
 
-	keep  bool
-	force bool
+	keep     bool
+	force    bool
+	cascade  bool
+	parallel int
 }{}
 
 var deleteCommand = &cobra.Command{
@@ -35,12 +38,23 @@ This sub-command will destroy cloud resources if the target has been
 deployed and removes the launch configuration. If you wish to retain
 the configuration in order to re-launch the target at a latter date
 then provide the --keep flag.
+
+A target that other targets depend on, such as a space with
+applications attached to it, can only be deleted along with its
+dependent targets by providing the --cascade flag. Dependent targets
+are deleted before the targets they depend on and independent targets
+are deleted in parallel.
 `,
 
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		DeleteTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(deleteFlags.commonFlags)))
+		targetKey := getTargetKeyFromArgs(args[0], args[1], args[2], &(deleteFlags.commonFlags))
+		if deleteFlags.cascade {
+			DeleteTargetCascade(targetKey)
+		} else {
+			DeleteTarget(targetKey)
+		}
 	},
 	Args: cobra.ExactArgs(3),
 }
@@ -50,20 +64,16 @@ func DeleteTarget(targetKey string) {
 	var (
 		err error
 
-		tgt  *target.Target
-		bldr *target.Builder
-
-		response string
+		tgt *target.Target
 	)
-	config := cbcli_config.Config
-	context := config.TargetContext()
+	context := cbcli_config.Config.TargetContext()
 
 	if tgt, err = context.GetTarget(targetKey); err == nil && tgt != nil {
 
 		if tgt.HasDependents() {
 			cbcli_utils.ShowErrorAndExit(
 				fmt.Sprintf(
-					"Target '%s' has dependent targets. Please delete all dependent targets before deleting this target.",
+					"Target '%s' has dependent targets. Please delete all dependent targets before deleting this target or provide the '--cascade' option.",
 					tgt.DeploymentName(),
 				),
 			)
@@ -80,60 +90,14 @@ func DeleteTarget(targetKey string) {
 			),
 		)
 		fmt.Println()
-		response = cbcli_utils.GetUserInput(
+		if cbcli_utils.GetConfirmationInput(
+			"confirm-delete-target",
 			"Confirm deletion by entering the deployment name: ",
-		)
-
-		if response == tgt.DeploymentName() {
-			if deleteFlags.force || tgt.Status() != target.Undeployed {
-				if bldr, err = tgt.NewBuilder(config.ContextVars(), os.Stdout, os.Stderr); err != nil {
-					cbcli_utils.ShowErrorAndExit(err.Error())
-				}
-				if tgt.CookbookVersion != tgt.Recipe.CookbookVersion() {
-					// force re-initializing
-					if err = bldr.Initialize(); err != nil {
-						cbcli_utils.ShowErrorAndExit(err.Error())
-					}
-				} else {
-					// initialize if required
-					if err = bldr.AutoInitialize(); err != nil {
-						cbcli_utils.ShowErrorAndExit(err.Error())
-					}
-				}
-				if err = bldr.Delete(); err != nil {
-					cbcli_utils.ShowErrorAndExit(err.Error())
-				}
-				tgt.Output = nil
-				context.SaveTarget(tgt.Key(), tgt)
-			}
-			if !deleteFlags.keep {
-				// delete target backend storage
-				if err = tgt.DeleteBackend(); err != nil {
-					logger.ErrorMessage("DeleteTarget(): Error deleting target's deployment state remote storage: %s", err.Error())
-					cbcli_utils.ShowNoteMessage("\nDeleting target's deployment state remote storage failed. You need to delete it manually from your cloud provider console.")
-				}
-				// delete target from config context
-				context.DeleteTarget(tgt.Key())
-
-				// delete target from MyCS account
-				if tgt.Recipe.IsBastion() {
-					// only recipes with a bastion instance is considered
-					// a space. TBD: this criteria should be revisited
-					spaceAPI := mycscloud.NewSpaceAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
-					if _, err = spaceAPI.DeleteSpace(tgt); err != nil {
-						logger.ErrorMessage("DeleteTarget(): Error attempting to delete space registration: %s", err.Error())
-						cbcli_utils.ShowNoteMessage("\nDeleting space registration failed. You may need to manually delete the space from the MyCS cloud dashboard.")
-					}
-
-				} else {
-					appAPI := mycscloud.NewAppAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
-					if _, err = appAPI.DeleteApp(tgt); err != nil {
-						logger.ErrorMessage("DeleteTarget(): Error attempting to delete app registration: %s", err.Error())
-						cbcli_utils.ShowNoteMessage("\nDeleting app registration failed. You may need to manually delete the app from the MyCS cloud dashboard.")
-					}
-				}
+			tgt.DeploymentName(),
+		) {
+			if err = deleteTarget(tgt, os.Stdout, os.Stderr); err != nil {
+				cbcli_utils.ShowErrorAndExit(err.Error())
 			}
-
 			fmt.Print(color.Green.Render("\nTarget has been deleted.\n\n"))
 		} else {
 			fmt.Print(color.Red.Render("\nTarget has not been deleted.\n\n"))
@@ -149,6 +113,161 @@ func DeleteTarget(targetKey string) {
 	)
 }
 
+// deletes the target along with all targets that depend
+// on it. dependent targets are deleted before the targets
+// they depend on and independent targets are deleted in
+// parallel.
+func DeleteTargetCascade(targetKey string) {
+
+	var (
+		err error
+
+		tgt *target.Target
+	)
+	context := cbcli_config.Config.TargetContext()
+
+	if tgt, err = context.GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+
+	// collect the target and all targets that
+	// depend on it directly or transitively
+	tasks := []*bulkTask{newBulkTask(tgt)}
+	taskIndex := map[string]*bulkTask{tgt.Key(): tasks[0]}
+	configured := configuredTargets()
+	for i := 0; i < len(tasks); i++ {
+		for _, ctgt := range configured {
+			if _, ok := taskIndex[ctgt.Key()]; ok {
+				continue
+			}
+			for _, dtgt := range ctgt.Dependencies() {
+				if dtgt.Key() == tasks[i].tgt.Key() {
+					task := newBulkTask(ctgt)
+					tasks = append(tasks, task)
+					taskIndex[ctgt.Key()] = task
+					break
+				}
+			}
+		}
+	}
+	// a target is deleted once all the
+	// targets that depend on it are deleted
+	for _, task := range tasks {
+		for _, dtgt := range task.tgt.Dependencies() {
+			if prereq, ok := taskIndex[dtgt.Key()]; ok {
+				prereq.prereqs = append(prereq.prereqs, task)
+			}
+		}
+	}
+
+	fmt.Println()
+	fmt.Print(
+		color.OpBold.Render(
+			utils.FormatMessage(
+				0, 80, false, true,
+				"Found %s.",
+				tgt.Name(),
+			),
+		),
+	)
+	fmt.Println()
+	if len(tasks) > 1 {
+		fmt.Println("\nThe following dependent targets will also be deleted:")
+		for _, task := range tasks[1:] {
+			fmt.Printf("  - %s\n", task.tgt.Key())
+		}
+		fmt.Println()
+	}
+	if !cbcli_utils.GetConfirmationInput(
+		"confirm-delete-target",
+		"Confirm deletion by entering the deployment name: ",
+		tgt.DeploymentName(),
+	) {
+		fmt.Print(color.Red.Render("\nTargets have not been deleted.\n\n"))
+		return
+	}
+
+	runBulkTasks(tasks, deleteFlags.parallel, "deleted", deleteTarget)
+}
+
+// destroys the target's deployed resources and unless
+// the configuration is to be kept removes the target's
+// configuration, backend state and registration
+func deleteTarget(tgt *target.Target, stdout, stderr io.Writer) error {
+
+	var (
+		err error
+
+		bldr *target.Builder
+	)
+	config := cbcli_config.Config
+	context := config.TargetContext()
+
+	if deleteFlags.force || tgt.Status() != target.Undeployed {
+		if bldr, err = tgt.NewBuilder(config.ContextVars(), stdout, stderr); err != nil {
+			return err
+		}
+		if tgt.CookbookVersion != tgt.Recipe.CookbookVersion() {
+			// force re-initializing
+			if err = bldr.Initialize(); err != nil {
+				return err
+			}
+		} else {
+			// initialize if required
+			if err = bldr.AutoInitialize(); err != nil {
+				return err
+			}
+		}
+		if err = bldr.Delete(); err != nil {
+			return err
+		}
+
+		targetsMx.Lock()
+		tgt.Output = nil
+		context.SaveTarget(tgt.Key(), tgt)
+		targetsMx.Unlock()
+	}
+	if !deleteFlags.keep {
+		// delete target backend storage
+		if err = tgt.DeleteBackend(); err != nil {
+			logger.ErrorMessage("DeleteTarget(): Error deleting target's deployment state remote storage: %s", err.Error())
+			cbcli_utils.WriteNoteMessage(stdout, "\nDeleting target's deployment state remote storage failed. You need to delete it manually from your cloud provider console.")
+		}
+		// delete target from config context
+		targetsMx.Lock()
+		context.DeleteTarget(tgt.Key())
+		err = cbcli_config.DeleteTargetSettings(tgt.Key())
+		targetsMx.Unlock()
+		if err != nil {
+			logger.ErrorMessage("DeleteTarget(): Error deleting target's settings: %s", err.Error())
+		}
+
+		// delete target from MyCS account
+		if tgt.Recipe.IsBastion() {
+			// only recipes with a bastion instance is considered
+			// a space. TBD: this criteria should be revisited
+			spaceAPI := mycscloud.NewSpaceAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+			if _, err = spaceAPI.DeleteSpace(tgt); err != nil {
+				logger.ErrorMessage("DeleteTarget(): Error attempting to delete space registration: %s", err.Error())
+				cbcli_utils.WriteNoteMessage(stdout, "\nDeleting space registration failed. You may need to manually delete the space from the MyCS cloud dashboard.")
+			}
+
+		} else {
+			appAPI := mycscloud.NewAppAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+			if _, err = appAPI.DeleteApp(tgt); err != nil {
+				logger.ErrorMessage("DeleteTarget(): Error attempting to delete app registration: %s", err.Error())
+				cbcli_utils.WriteNoteMessage(stdout, "\nDeleting app registration failed. You may need to manually delete the app from the MyCS cloud dashboard.")
+			}
+		}
+	}
+	return nil
+}
+
 func init() {
 	flags := deleteCommand.Flags()
 	flags.SortFlags = false
@@ -156,4 +275,6 @@ func init() {
 
 	flags.BoolVarP(&deleteFlags.keep, "keep", "k", false, "destroy deployed resources if any but do not delete the configuration")
 	flags.BoolVarP(&deleteFlags.force, "force", "f", false, "run delete on target even if status is undeployed")
+	flags.BoolVarP(&deleteFlags.cascade, "cascade", "c", false, "delete the target along with all targets that depend on it")
+	flags.IntVarP(&deleteFlags.parallel, "parallel", "P", 4, "maximum number of targets to delete in parallel")
 }
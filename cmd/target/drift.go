@@ -0,0 +1,280 @@
+package target
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/termtables"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+const (
+	// exit code returned when drift is detected
+	ExitCodeDrift = 2
+
+	driftChanged = "changed"
+	driftDeleted = "deleted"
+)
+
+var driftFlags = struct {
+	commonFlags
+
+	all bool
+}{}
+
+var driftCommand = &cobra.Command{
+	Use: "drift [recipe] [cloud] [deployment name]",
+
+	Short: "Detect changes made to a target's resources outside of the CLI.",
+	Long: `
+Refreshes the state of a deployed target's cloud resources and reports
+the resources that Terraform detects have been changed or deleted
+outside of Terraform since the target was last launched. Provide the
+'--all' option instead of a target to check all deployed targets.
+Detecting drift does not change the target or its launch context.
+
+Only the resources in the target's Terraform state are refreshed, so
+resources that were added to the target's cloud account or network
+outside of the CLI are not reported. Deleted resources are reported
+as they will be re-created when the target is launched again.
+
+The command exits with code 2 if drift was detected and with code 1
+if drift could not be determined for a target or a target could not
+be loaded, so it can be run on a timer to detect changes made to
+targets outside of the CLI.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if driftFlags.all {
+			DetectDrift(configuredTargets())
+		} else {
+			DetectTargetDrift(getTargetKeyFromArgs(args[0], args[1], args[2], &(driftFlags.commonFlags)))
+		}
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if driftFlags.all {
+			return cobra.ExactArgs(0)(cmd, args)
+		}
+		return cobra.ExactArgs(3)(cmd, args)
+	},
+}
+
+// structured output schema for the drift of a target.
+// the schema is documented in doc/output.md and fields
+// should only be added to it.
+type driftOutput struct {
+	Key       string                `json:"key" yaml:"key"`
+	Drifted   bool                  `json:"drifted" yaml:"drifted"`
+	Resources []driftResourceOutput `json:"resources" yaml:"resources"`
+	Error     string                `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type driftResourceOutput struct {
+	Address string `json:"address" yaml:"address"`
+	Drift   string `json:"drift" yaml:"drift"`
+}
+
+func DetectTargetDrift(targetKey string) {
+
+	var (
+		err error
+
+		tgt *target.Target
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+	if tgt.Status() == target.Undeployed {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Target \"%s\" has not been deployed", tgt.DeploymentName()))
+	}
+	DetectDrift([]*target.Target{tgt})
+}
+
+// detects drift of the given targets and sets the
+// exit code of the CLI if drift was detected or
+// could not be determined for any target
+func DetectDrift(tgts []*target.Target) {
+
+	drifted, failed := false, false
+	output := []driftOutput{}
+
+	for _, tgt := range tgts {
+		var result driftOutput
+
+		switch {
+		case tgt.Error() != nil:
+			// targets that could not be loaded
+			// are reported as failed
+			result = driftOutput{
+				Key:       tgt.Key(),
+				Resources: []driftResourceOutput{},
+				Error:     tgt.Error().Error(),
+			}
+		case tgt.Status() == target.Undeployed:
+			continue
+		default:
+			result = detectDrift(tgt)
+		}
+		drifted = drifted || result.Drifted
+		failed = failed || len(result.Error) > 0
+
+		if cbcli_utils.IsTableOutput() {
+			showDrift(tgt, result)
+		} else {
+			output = append(output, result)
+		}
+	}
+
+	if !cbcli_utils.IsTableOutput() {
+		cbcli_utils.RenderOutput(output)
+	} else if len(tgts) > 1 || driftFlags.all {
+		fmt.Println()
+		switch {
+		case failed:
+			cbcli_utils.ShowWarningMessage("Drift could not be determined for some targets.")
+		case drifted:
+			cbcli_utils.ShowWarningMessage("Drift was detected.")
+		default:
+			cbcli_utils.ShowInfoMessage("No drift was detected.")
+		}
+		fmt.Println()
+	}
+
+	switch {
+	case failed:
+		cbcli_utils.ExitCode = 1
+	case drifted:
+		cbcli_utils.ExitCode = ExitCodeDrift
+	}
+}
+
+// computes the target's launch plan which refreshes the
+// state of its resources and returns the resources that
+// terraform reports have been changed outside of it
+func detectDrift(tgt *target.Target) driftOutput {
+
+	var (
+		err error
+
+		bldr *target.Builder
+	)
+
+	result := driftOutput{
+		Key:       tgt.Key(),
+		Resources: []driftResourceOutput{},
+	}
+
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(fmt.Sprintf(" Refreshing the state of target \"%s\".", tgt.DeploymentName())),
+		spinner.WithFinalMSG(""),
+		spinner.WithHiddenCursor(true),
+	)
+	if cbcli_utils.IsTableOutput() {
+		s.Start()
+	}
+
+	// the launch context is only initialized if it has not
+	// been initialized so that detecting drift does not
+	// re-initialize it for a newer cookbook
+	stdout := newPlanRecorder(io.Discard)
+	if bldr, err = tgt.NewBuilder(cbcli_config.Config.ContextVars(), stdout, os.Stderr); err == nil {
+		if err = bldr.AutoInitialize(); err == nil {
+			stdout.start(false)
+			if err = bldr.ShowLaunchPlan(); err == nil {
+				_, err = stdout.stop()
+			}
+		}
+	}
+	s.Stop()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// only the changes terraform reports were made outside
+	// of it are drift. the actions of the plan may also be
+	// due to changes to the target's configuration.
+	drift := make(map[string]string)
+	for address, change := range parseExternalChanges(stdout.output()) {
+		if change == cbcli_cookbook.PlanDestroy {
+			drift[address] = driftDeleted
+		} else {
+			drift[address] = driftChanged
+		}
+	}
+
+	addresses := make([]string, 0, len(drift))
+	for address := range drift {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		result.Resources = append(result.Resources, driftResourceOutput{
+			Address: address,
+			Drift:   drift[address],
+		})
+	}
+	result.Drifted = len(result.Resources) > 0
+	return result
+}
+
+func showDrift(tgt *target.Target, result driftOutput) {
+
+	fmt.Println()
+	switch {
+	case len(result.Error) > 0:
+		cbcli_utils.ShowErrorMessage(
+			fmt.Sprintf("Unable to detect drift of target \"%s\": %s", tgt.DeploymentName(), result.Error))
+
+	case !result.Drifted:
+		cbcli_utils.ShowInfoMessage("Target \"%s\" has not drifted.", tgt.DeploymentName())
+
+	default:
+		cbcli_utils.ShowWarningMessage(
+			"Target \"%s\" has drifted. The following resources have been changed outside of Terraform.\n",
+			tgt.DeploymentName(),
+		)
+		table := termtables.CreateTable()
+		table.AddHeaders(
+			color.OpBold.Render("Resource"),
+			color.OpBold.Render("Drift"),
+		)
+		for _, resource := range result.Resources {
+			table.AddRow(resource.Address, resource.Drift)
+		}
+		fmt.Println(table.Render())
+	}
+}
+
+func init() {
+	flags := driftCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(driftFlags.commonFlags))
+
+	flags.BoolVarP(&driftFlags.all, "all", "a", false,
+		"detect drift of all deployed targets")
+}
@@ -0,0 +1,235 @@
+package target
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/gocloud/cloud"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var execFlags = struct {
+	commonFlags
+
+	instance     string
+	allInstances bool
+}{}
+
+var execCommand = &cobra.Command{
+	Use: "exec [recipe] [cloud] [deployment name] -- [command]",
+
+	Short: "Run a command on a launch target's instances.",
+	Long: `
+Runs a command over SSH on one of the target's running instances and
+streams its output. The CLI exits with the exit code of the remote
+command. If the target has more than one instance provide the instance
+to run the command on via the '-i|--instance' option or run the
+command on all instances in parallel with the '-a|--all-instances'
+option. When run on all instances each line of output is prefixed
+with the name of the instance it came from and the CLI exits with the
+highest exit code of the command on any instance.
+
+Each argument of the command is quoted for the remote shell so it is
+passed to the command as given. To run a shell pipeline or expand
+variables on the instance pass the command to 'sh -c'.
+
+For example:
+
+  cb target exec sandbox aws MyVPN -r us-east-1 -a -- uptime
+  cb target exec sandbox aws MyVPN -r us-east-1 -- sh -c 'df -h | grep /data'
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ExecTarget(
+			getTargetKeyFromArgs(args[0], args[1], args[2], &(execFlags.commonFlags)),
+			remoteCommand(args[3:]),
+		)
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 3 || len(args) < 4 {
+			return fmt.Errorf("expected a target followed by '--' and the command to run")
+		}
+		return nil
+	},
+}
+
+// returns the command line that runs the given
+// arguments on an instance with each argument
+// quoted for the remote shell
+func remoteCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quotes a string to be used as a single
+// argument of a posix shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func ExecTarget(targetKey, command string) {
+
+	var (
+		err error
+
+		tgt       *target.Target
+		instances []*target.ManagedInstance
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+
+	switch {
+	case execFlags.allInstances:
+		instances = tgt.ManagedInstances()
+	case len(execFlags.instance) > 0:
+		if instances, err = selectManagedInstances(tgt, []string{execFlags.instance}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	default:
+		if instances = tgt.ManagedInstances(); len(instances) > 1 {
+			names := []string{}
+			for _, instance := range instances {
+				names = append(names, instance.Name())
+			}
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Target is running more than one managed instance (\"%s\"). Provide the instance to run the command on via '--instance' or use '--all-instances'.",
+					strings.Join(names, "\", \""),
+				),
+			)
+		}
+	}
+	if len(instances) == 0 {
+		cbcli_utils.ShowErrorAndExit("No managed instances have been deployed.")
+	}
+
+	if len(instances) == 1 {
+		exitCode, err := execInstanceCommand(tgt, instances[0], command, os.Stdout, os.Stderr)
+		if err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		cbcli_utils.ExitCode = exitCode
+		return
+	}
+
+	nameWidth := 0
+	for _, instance := range instances {
+		if len(instance.Name()) > nameWidth {
+			nameWidth = len(instance.Name())
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		resultMx sync.Mutex
+	)
+	outMx := &sync.Mutex{}
+
+	for _, instance := range instances {
+		wg.Add(1)
+		go func(instance *target.ManagedInstance) {
+			defer wg.Done()
+
+			prefix := fmt.Sprintf("[%-*s] ", nameWidth, instance.Name())
+			stdout := newPrefixWriter(os.Stdout, color.Cyan.Render(prefix), outMx)
+			stderr := newPrefixWriter(os.Stderr, color.Red.Render(prefix), outMx)
+
+			exitCode, err := execInstanceCommand(tgt, instance, command, stdout, stderr)
+			if err != nil {
+				fmt.Fprintln(stderr, err.Error())
+				exitCode = 1
+			}
+			stdout.flush()
+			stderr.flush()
+
+			resultMx.Lock()
+			defer resultMx.Unlock()
+			if exitCode > cbcli_utils.ExitCode {
+				cbcli_utils.ExitCode = exitCode
+			}
+		}(instance)
+	}
+	wg.Wait()
+}
+
+// runs the command on the managed instance and returns
+// the exit code of the command. an error is returned
+// only if the command could not be run.
+func execInstanceCommand(
+	tgt *target.Target,
+	instance *target.ManagedInstance,
+	command string,
+	stdout, stderr io.Writer,
+) (int, error) {
+
+	var (
+		err error
+
+		state   cloud.InstanceState
+		client  *instanceConnection
+		session *ssh.Session
+	)
+
+	if state, err = instance.State(); err != nil {
+		return 0, err
+	}
+	if state != cloud.StateRunning {
+		return 0, fmt.Errorf("instance \"%s\" is not running", instance.Name())
+	}
+	if client, err = dialManagedInstance(tgt, instance); err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	if session, err = client.NewSession(); err != nil {
+		return 0, err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err = session.Run(command); err != nil {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitStatus(), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+func init() {
+	flags := execCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(execFlags.commonFlags))
+
+	flags.StringVarP(&execFlags.instance, "instance", "i", "",
+		"name of the instance to run the command on")
+	flags.BoolVarP(&execFlags.allInstances, "all-instances", "a", false,
+		"run the command on all of the target's instances in parallel")
+}
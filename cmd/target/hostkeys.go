@@ -0,0 +1,160 @@
+package target
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/goutils/logger"
+
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+)
+
+// recipe output with the SSH host keys or their SHA256
+// fingerprints of the recipe's instances keyed by name
+const hostKeysOutput = "cb_managed_instance_host_keys"
+
+// serializes updates to the recorded host keys as
+// instances may be connected to in parallel
+var hostKeysMx sync.Mutex
+
+// returns a host key callback that verifies the instance's
+// host key against the key published by the target's recipe
+// or the key recorded when the instance was first connected
+// to. if no key is known the instance's key is recorded.
+func hostKeyCallback(tgt *target.Target, managedInstance *target.ManagedInstance) ssh.HostKeyCallback {
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+
+		fingerprint := ssh.FingerprintSHA256(key)
+		expected, err := recipeHostKey(tgt, managedInstance.Name())
+		if err != nil {
+			// a host key published by the recipe that cannot
+			// be verified must not fall back to recording
+			// the key presented by the instance
+			return err
+		}
+		if len(expected) > 0 {
+			if fingerprint != expected {
+				return hostKeyMismatchError(tgt, managedInstance, expected, fingerprint, false)
+			}
+			return nil
+		}
+
+		hostKeysMx.Lock()
+		defer hostKeysMx.Unlock()
+
+		// the key is verified and recorded within the
+		// update so that only this target's settings
+		// are written back to the encrypted store
+		return cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+			if expected, exists := settings.HostKeys[managedInstance.Name()]; exists {
+				if fingerprint != expected {
+					return hostKeyMismatchError(tgt, managedInstance, expected, fingerprint, true)
+				}
+				return nil
+			}
+
+			logger.TraceMessage(
+				"Recording host key %s of instance \"%s\" of target \"%s\".",
+				fingerprint, managedInstance.Name(), tgt.Key(),
+			)
+			if settings.HostKeys == nil {
+				settings.HostKeys = make(map[string]string)
+			}
+			settings.HostKeys[managedInstance.Name()] = fingerprint
+			return nil
+		})
+	}
+}
+
+// discards the recorded host key of the instance so that
+// its current key is recorded on the next connection
+func resetHostKey(tgt *target.Target, managedInstance *target.ManagedInstance) error {
+
+	hostKeysMx.Lock()
+	defer hostKeysMx.Unlock()
+
+	return cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+		delete(settings.HostKeys, managedInstance.Name())
+		return nil
+	})
+}
+
+func hostKeyMismatchError(
+	tgt *target.Target,
+	managedInstance *target.ManagedInstance,
+	expected, fingerprint string,
+	recorded bool,
+) error {
+
+	msg := fmt.Sprintf(
+		"WARNING! The host key of instance \"%s\" of target \"%s\" has changed. "+
+			"Expected %s but the instance presented %s. Someone may be intercepting the "+
+			"connection, so the connection has been refused.",
+		managedInstance.Name(), tgt.DeploymentName(), expected, fingerprint,
+	)
+	if recorded {
+		msg += " If the instance has been rebuilt run 'cb target ssh' with the '--reset-host-key' option to discard the recorded host key."
+	}
+	return errors.New(msg)
+}
+
+// returns the SHA256 fingerprint of the instance's host key
+// if the target's recipe publishes the host keys of its
+// instances as an output. an error is returned if a key
+// is published for the instance but cannot be parsed.
+func recipeHostKey(tgt *target.Target, name string) (string, error) {
+
+	var (
+		err error
+
+		data []byte
+	)
+
+	// the outputs are read generically as only the
+	// value of the host keys output is of interest
+	outputs := make(map[string]struct {
+		Value interface{} `json:"value"`
+	})
+	if data, err = json.Marshal(tgt.Output); err != nil {
+		return "", err
+	}
+	if err = json.Unmarshal(data, &outputs); err != nil {
+		return "", err
+	}
+	hostKeys, ok := outputs[hostKeysOutput].Value.(map[string]interface{})
+	if !ok {
+		return "", nil
+	}
+	value, exists := hostKeys[name]
+	if !exists {
+		return "", nil
+	}
+	hostKey, ok := value.(string)
+	if !ok || len(hostKey) == 0 {
+		return "", invalidRecipeHostKeyError(tgt, name, "the key is not a string")
+	}
+	if strings.HasPrefix(hostKey, "SHA256:") {
+		return hostKey, nil
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return "", invalidRecipeHostKeyError(tgt, name, err.Error())
+	}
+	return ssh.FingerprintSHA256(key), nil
+}
+
+func invalidRecipeHostKeyError(tgt *target.Target, name, reason string) error {
+	return fmt.Errorf(
+		"the host key published by the recipe of target \"%s\" for instance \"%s\" is invalid (%s), "+
+			"so the instance's host key cannot be verified and the connection has been refused",
+		tgt.DeploymentName(), name, reason,
+	)
+}
@@ -0,0 +1,235 @@
+package target
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/mevansam/gocloud/cloud"
+	"github.com/mevansam/goutils/logger"
+
+	"github.com/appbricks/cloud-builder/target"
+
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+)
+
+// status name of a target where only
+// some of its instances are running
+const partiallySuspended = "partially-suspended"
+
+// returns the target's managed instances with the given names
+// in deployment order. if no names are given then all of the
+// target's managed instances are returned.
+func selectManagedInstances(tgt *target.Target, names []string) ([]*target.ManagedInstance, error) {
+
+	managedInstances := tgt.ManagedInstances()
+	if len(names) == 0 {
+		return managedInstances, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[name] = true
+	}
+	instances := []*target.ManagedInstance{}
+	for _, managedInstance := range managedInstances {
+		if selected[managedInstance.Name()] {
+			instances = append(instances, managedInstance)
+			delete(selected, managedInstance.Name())
+		}
+	}
+	if len(selected) > 0 {
+		unknown := []string{}
+		for _, name := range names {
+			if selected[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		available := []string{}
+		for _, managedInstance := range managedInstances {
+			available = append(available, managedInstance.Name())
+		}
+		return nil, fmt.Errorf(
+			"target \"%s\" does not have instance(s) \"%s\". Its instances are \"%s\"",
+			tgt.DeploymentName(),
+			strings.Join(unknown, "\", \""),
+			strings.Join(available, "\", \""),
+		)
+	}
+	return instances, nil
+}
+
+// returns true if some but not all of the target's
+// instances have been suspended. this is determined
+// from the instances recorded as suspended so that
+// the cloud does not need to be queried.
+func isPartiallySuspended(tgt *target.Target) bool {
+
+	var (
+		err error
+
+		settings *cbcli_config.TargetSettings
+	)
+
+	if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil {
+		return false
+	}
+	return len(settings.SuspendedInstances) > 0 &&
+		len(settings.SuspendedInstances) < len(tgt.ManagedInstances())
+}
+
+// records the given instances as suspended or resumed. if
+// none or all of the target's instances end up suspended
+// then the recorded instances are cleared as the target's
+// status reflects its state.
+func recordSuspendedInstances(tgt *target.Target, instances []*target.ManagedInstance, suspended bool) {
+
+	err := cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+		names := make(map[string]bool)
+		for _, name := range settings.SuspendedInstances {
+			names[name] = true
+		}
+		for _, instance := range instances {
+			names[instance.Name()] = suspended
+		}
+		settings.SuspendedInstances = nil
+		for _, managedInstance := range tgt.ManagedInstances() {
+			if names[managedInstance.Name()] {
+				settings.SuspendedInstances = append(settings.SuspendedInstances, managedInstance.Name())
+			}
+		}
+		if len(settings.SuspendedInstances) == len(tgt.ManagedInstances()) {
+			settings.SuspendedInstances = nil
+		}
+		return nil
+	})
+	if err != nil {
+		logger.ErrorMessage("recordSuspendedInstances(): Unable to record the suspended instances of target \"%s\": %s", tgt.Key(), err.Error())
+	}
+}
+
+// stops the given instances in the reverse order
+// of deployment so that instances other instances
+// depend on, such as databases, are stopped last
+func suspendInstances(instances []*target.ManagedInstance) error {
+
+	var (
+		err error
+
+		state cloud.InstanceState
+	)
+
+	for i := len(instances) - 1; i >= 0; i-- {
+		instance := instances[i]
+		name := instance.Name()
+
+		if state, err = instance.State(); err != nil {
+			return err
+		}
+		switch state {
+		case cloud.StateStopped:
+			fmt.Printf("Instance \"%s\" is already stopped.\n\n", name)
+			continue
+		case cloud.StateRunning:
+		default:
+			return fmt.Errorf("instance \"%s\" is in a pending state and cannot be stopped", name)
+		}
+
+		s := spinner.New(
+			spinner.CharSets[cbcli_config.SpinnerNetworkType],
+			100*time.Millisecond,
+			spinner.WithSuffix(fmt.Sprintf(" Stopping instance \"%s\".", name)),
+			spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" stopped.\n\n", name)),
+			spinner.WithHiddenCursor(true),
+		)
+		s.Start()
+		err = instance.Instance.Stop()
+		s.Stop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// starts the given instances in order of deployment
+// and waits for instances with a public IP to accept
+// connections before starting the next instance
+func resumeInstances(instances []*target.ManagedInstance) error {
+
+	var (
+		err error
+
+		state cloud.InstanceState
+	)
+
+	for _, instance := range instances {
+		name := instance.Name()
+
+		if state, err = instance.State(); err != nil {
+			return err
+		}
+		switch state {
+		case cloud.StateRunning:
+			fmt.Printf("Instance \"%s\" is already running.\n\n", name)
+			continue
+		case cloud.StateStopped:
+		default:
+			return fmt.Errorf("instance \"%s\" is in a pending state and cannot be started", name)
+		}
+
+		s := spinner.New(
+			spinner.CharSets[cbcli_config.SpinnerNetworkType],
+			100*time.Millisecond,
+			spinner.WithSuffix(fmt.Sprintf(" Starting instance \"%s\".", name)),
+			spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" started.\n\n", name)),
+			spinner.WithHiddenCursor(true),
+		)
+		s.Start()
+		if err = instance.Instance.Start(); err == nil && len(instance.PublicIP()) > 0 {
+			for {
+				ok, err := instance.CanConnect()
+				if ok || err != nil {
+					if err != nil {
+						logger.ErrorMessage(err.Error())
+					}
+					break
+				}
+				time.Sleep(time.Second * 5)
+			}
+		}
+		s.Stop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// records why the target was suspended
+func recordSuspension(tgt *target.Target, reason string) {
+
+	err := cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+		settings.Suspended = &cbcli_config.SuspendRecord{
+			Reason: reason,
+			At:     time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		logger.ErrorMessage("recordSuspension(): Unable to record why target \"%s\" was suspended: %s", tgt.Key(), err.Error())
+	}
+}
+
+// clears the recorded reason the target was suspended
+func clearSuspension(tgt *target.Target) {
+
+	err := cbcli_config.UpdateTargetSettings(tgt.Key(), func(settings *cbcli_config.TargetSettings) error {
+		settings.Suspended = nil
+		return nil
+	})
+	if err != nil {
+		logger.ErrorMessage("clearSuspension(): Unable to clear why target \"%s\" was suspended: %s", tgt.Key(), err.Error())
+	}
+}
@@ -2,8 +2,11 @@ package target
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/briandowns/spinner"
 	"github.com/mevansam/goutils/logger"
 	"github.com/spf13/cobra"
 
@@ -12,6 +15,7 @@ import (
 
 	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
 	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
 	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
 )
 
@@ -22,6 +26,13 @@ var launchFlags = struct {
 	rebuild      bool
 	cleanRebuild bool
 	plan         bool
+	planOut      string
+
+	maxMonthlyCost float64
+
+	all      bool
+	selector string
+	parallel int
 }{}
 
 var launchCommand = &cobra.Command{
@@ -32,14 +43,58 @@ var launchCommand = &cobra.Command{
 Deploys a quick launch target or re-applies any configuration
 updates. Rebuild and Clean-rebuild options are complementary and
 clean-rebuild takes precedence. 
+
+The launch plan shows an estimate of the monthly cost of the
+resources it creates or changes and of the target once it is applied.
+Provide the '--max-monthly-cost' option to refuse to launch if the
+estimated monthly cost of the target once launched exceeds the given
+amount or if the cost of any of its resources cannot be estimated.
+The estimate is of a plan computed just before the launch, which
+computes its plan again. If the plan that was launched differs from
+the one that was estimated, or cannot be determined, the cost of the
+launched target is estimated again and the launch fails if it exceeds
+the maximum or cannot be estimated.
+
+To review a change before it is made provide the '--plan-out' option
+to save the launch plan to a file. The saved plan can be applied with
+'cb target apply' as long as the target's configuration, cookbook and
+deployed resources have not changed since the plan was saved.
+
+Provide the '--all' option instead of a target to launch all
+configured targets or the '--selector' option to launch the targets
+that match a selector of comma separated 'field=pattern' pairs where
+field is one of 'recipe', 'cloud', 'region', 'name' or 'type' (space
+or app) and pattern is a glob pattern. For example:
+
+  cb target launch --selector type=space,cloud=aws
+
+Spaces are launched before the applications attached to them and
+independent targets are launched in parallel.
 `,
 
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		LaunchTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(launchFlags.commonFlags)))
+		if launchFlags.all || len(launchFlags.selector) > 0 {
+			if launchFlags.plan || len(launchFlags.planOut) > 0 || launchFlags.maxMonthlyCost > 0 {
+				cbcli_utils.ShowErrorAndExit(
+					"The plan, plan-out and max-monthly-cost options cannot be used when launching multiple targets.")
+			}
+			tgts, err := selectTargets(launchFlags.selector)
+			if err != nil {
+				cbcli_utils.ShowErrorAndExit(err.Error())
+			}
+			LaunchTargets(tgts)
+		} else {
+			LaunchTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(launchFlags.commonFlags)))
+		}
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if launchFlags.all || len(launchFlags.selector) > 0 {
+			return cobra.ExactArgs(0)(cmd, args)
+		}
+		return cobra.ExactArgs(3)(cmd, args)
 	},
-	Args: cobra.ExactArgs(3),
 }
 
 func LaunchTarget(targetKey string) {
@@ -49,6 +104,9 @@ func LaunchTarget(targetKey string) {
 
 		tgt, spaceTgt *target.Target
 		bldr          *target.Builder
+
+		resources []*cbcli_cookbook.PlannedResource
+		estimate  *cbcli_cookbook.CostEstimate
 	)
 	config := cbcli_config.Config
 	context := config.TargetContext()
@@ -65,67 +123,112 @@ func LaunchTarget(targetKey string) {
 			}
 		}
 
-		if bldr, err = tgt.NewBuilder(config.ContextVars(), os.Stdout, os.Stderr); err != nil {
-			cbcli_utils.ShowErrorAndExit(err.Error())
-		}
 		fmt.Println()
-
-		if err = tgt.PrepareBackend(); err != nil {
-			// ensure backend state storage resources
-			// are created
+		stdout := newPlanRecorder(os.Stdout)
+		if bldr, err = newTargetBuilder(tgt, stdout, os.Stderr, launchFlags.init); err != nil {
 			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
-		if launchFlags.init ||
-			tgt.CookbookVersion != tgt.Recipe.CookbookVersion() {
-			// force re-initializing
-			if err = bldr.Initialize(); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
-		} else {
-			// initialize if not initialized
-			if err = bldr.AutoInitialize(); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
-		}
 
-		if launchFlags.cleanRebuild {
-			// mark target instance resource data to be
-			// rebuilt on next launch
-			if err = bldr.SetRebuildInstanceData(); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
-		}
-		if launchFlags.cleanRebuild || launchFlags.rebuild {
-			// mark target instance resources to be
-			// rebuilt on next launch
-			if err = bldr.SetRebuildInstances(); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
+		if err = setRebuild(bldr, launchFlags.rebuild, launchFlags.cleanRebuild); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
 
-		if launchFlags.plan {
+		if launchFlags.plan || len(launchFlags.planOut) > 0 {
 			// show launch plan
+			stdout.start(true)
 			if err = bldr.ShowLaunchPlan(); err != nil {
 				cbcli_utils.ShowErrorAndExit(err.Error())
 			}
 			tgt.CookbookVersion = tgt.Recipe.CookbookVersion()
 			context.SaveTarget(tgt.Key(), tgt)
 
-		} else {
-			// deploy target recipe to cloud
-			if err = bldr.Launch(); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
+			resources, err = stdout.stop()
+			if len(launchFlags.planOut) > 0 {
+				if err == nil {
+					err = saveLaunchPlan(launchFlags.planOut, tgt, stdout.output(), resources)
+				}
+				if err != nil {
+					cbcli_utils.ShowErrorAndExit(
+						fmt.Sprintf("Unable to save the launch plan to '%s': %s", launchFlags.planOut, err.Error()))
+				}
+				fmt.Println()
+				cbcli_utils.ShowInfoMessage(
+					"The launch plan has been saved to '%s'. Run 'cb target apply %s' to apply it.",
+					launchFlags.planOut, launchFlags.planOut,
+				)
+			}
+			if err == nil {
+				estimate, err = estimateCost(tgt, resources)
+			}
+			if err != nil {
+				cbcli_utils.ShowWarningMessage("\nUnable to estimate the cost of the launch plan: %s", err.Error())
+				return
+			}
+			showCostEstimate(estimate)
+			if launchFlags.maxMonthlyCost > 0 {
+				if err = checkMaxMonthlyCost(estimate, launchFlags.maxMonthlyCost); err != nil {
+					cbcli_utils.ShowWarningMessage("The target will not be launched with '--max-monthly-cost' as %s.\n", err.Error())
+				}
 			}
 
-			// retrieve the output of the deployment
-			output := bldr.Output()
-			logger.TraceMessage("Launch output: %# v", output)
+		} else {
+			if launchFlags.maxMonthlyCost > 0 {
+				// refuse to launch if the estimated cost
+				// of the launch plan exceeds the maximum
+				s := spinner.New(
+					spinner.CharSets[cbcli_config.SpinnerNetworkType],
+					100*time.Millisecond,
+					spinner.WithSuffix(" Estimating the cost of the launch plan."),
+					spinner.WithFinalMSG(""),
+					spinner.WithHiddenCursor(true),
+				)
+				s.Start()
+				stdout.start(false)
+				if err = bldr.ShowLaunchPlan(); err == nil {
+					if resources, err = stdout.stop(); err == nil {
+						estimate, err = estimateCost(tgt, resources)
+					}
+				}
+				s.Stop()
+				if err != nil {
+					cbcli_utils.ShowErrorAndExit(
+						fmt.Sprintf("Unable to estimate the cost of the launch plan: %s", err.Error()))
+				}
+				if err = checkMaxMonthlyCost(estimate, launchFlags.maxMonthlyCost); err != nil {
+					showCostEstimate(estimate)
+					cbcli_utils.ShowErrorAndExit(fmt.Sprintf("The target will not be launched as %s", err.Error()))
+				}
 
-			tgt.Output = output
-			tgt.CookbookVersion = tgt.Recipe.CookbookVersion()
-			context.SaveTarget(tgt.Key(), tgt)
+				// the builder plans the launch again so the plan
+				// in the launch output is compared with the plan
+				// that was estimated
+				estimatedHash := planHash(stdout.output(), resources)
+				if resources, err = launchTargetResources(tgt, bldr, stdout); err != nil {
+					cbcli_utils.ShowErrorAndExit(err.Error())
+				}
+				if appliedPlanChanged(stdout, resources, estimatedHash) {
+					// the launched target's resources are
+					// recorded so its cost is estimated
+					// without a plan
+					if estimate, err = estimateCost(tgt, []*cbcli_cookbook.PlannedResource{}); err == nil {
+						err = checkMaxMonthlyCost(estimate, launchFlags.maxMonthlyCost)
+					}
+					if err != nil {
+						fmt.Println()
+						cbcli_utils.ShowErrorMessage(
+							fmt.Sprintf(
+								"The plan launched for target \"%s\" differed from the plan that was estimated and the monthly cost of the launched target is not within the maximum of %.2f: %s.",
+								tgt.DeploymentName(), launchFlags.maxMonthlyCost, err.Error(),
+							),
+						)
+						cbcli_utils.ExitCode = 1
+					}
+				}
+				showNodeInfo(tgt)
+				return
+			}
 
-			showNodeInfo(tgt)
+			deployTarget(tgt, bldr, stdout)
 		}
 		return
 	}
@@ -138,6 +241,210 @@ func LaunchTarget(targetKey string) {
 	)
 }
 
+// launches the given targets ordering the launch
+// of spaces before the applications attached to
+// them and launching independent targets in
+// parallel
+func LaunchTargets(tgts []*target.Target) {
+
+	if len(tgts) == 0 {
+		cbcli_utils.ShowErrorAndExit("No configured targets were selected to launch.")
+	}
+
+	tasks := make([]*bulkTask, 0, len(tgts))
+	taskIndex := make(map[string]*bulkTask)
+	for _, tgt := range tgts {
+		task := newBulkTask(tgt)
+		tasks = append(tasks, task)
+		taskIndex[tgt.Key()] = task
+	}
+	for _, task := range tasks {
+		for _, dtgt := range task.tgt.Dependencies() {
+			if prereq, ok := taskIndex[dtgt.Key()]; ok {
+				task.prereqs = append(task.prereqs, prereq)
+			}
+		}
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("Launching %d targets.\n", len(tasks))
+
+	runBulkTasks(tasks, launchFlags.parallel, "launched",
+		func(tgt *target.Target, out, stderr io.Writer) error {
+
+			var (
+				err error
+
+				bldr *target.Builder
+			)
+			stdout := newPlanRecorder(out)
+
+			// dependencies that are not being launched
+			// must have already been deployed
+			for _, dtgt := range tgt.Dependencies() {
+				if _, ok := taskIndex[dtgt.Key()]; !ok && dtgt.Status() == target.Undeployed {
+					return fmt.Errorf("target \"%s\" it depends on has not been deployed", dtgt.DeploymentName())
+				}
+			}
+			if bldr, err = newTargetBuilder(tgt, stdout, stderr, launchFlags.init); err != nil {
+				return err
+			}
+			if err = setRebuild(bldr, launchFlags.rebuild, launchFlags.cleanRebuild); err != nil {
+				return err
+			}
+			_, err = launchTargetResources(tgt, bldr, stdout)
+			return err
+		},
+	)
+}
+
+// deploys the target recipe to the cloud
+// and shows the deployed target
+func deployTarget(tgt *target.Target, bldr *target.Builder, stdout *planRecorder) {
+	if _, err := launchTargetResources(tgt, bldr, stdout); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	showNodeInfo(tgt)
+}
+
+// deploys the target recipe to the cloud and saves the
+// output of the deployment. the builder's output must
+// be written to the given recorder so that the plan the
+// launch applies can be recorded. the resources of the
+// applied plan are returned or nil if they could not be
+// determined from the launch output.
+func launchTargetResources(
+	tgt *target.Target,
+	bldr *target.Builder,
+	stdout *planRecorder,
+) ([]*cbcli_cookbook.PlannedResource, error) {
+
+	var (
+		err, planErr error
+
+		applied []*cbcli_cookbook.PlannedResource
+	)
+	wasDeployed := tgt.Status() != target.Undeployed
+
+	// deploy target recipe to cloud
+	stdout.start(true)
+	err = bldr.Launch()
+	if applied, planErr = stdout.stop(); planErr != nil {
+		logger.DebugMessage("launchTargetResources(): Unable to determine the applied plan from the launch output: %s", planErr.Error())
+	}
+	if err != nil {
+		applied = nil
+	}
+	// the deployed resources are used to
+	// estimate the cost of the target
+	if planErr = recordDeployedResources(tgt, wasDeployed, applied); planErr != nil {
+		logger.DebugMessage("launchTargetResources(): Unable to record the deployed resources: %s", planErr.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// retrieve the output of the deployment
+	output := bldr.Output()
+	logger.TraceMessage("Launch output: %# v", output)
+
+	targetsMx.Lock()
+	defer targetsMx.Unlock()
+
+	tgt.Output = output
+	tgt.CookbookVersion = tgt.Recipe.CookbookVersion()
+	cbcli_config.Config.TargetContext().SaveTarget(tgt.Key(), tgt)
+	return applied, nil
+}
+
+// returns whether the plan applied by a launch, recorded
+// from the launch output, differs from the plan with the
+// given hash. if the applied plan could not be determined
+// from the output it is considered to have changed.
+func appliedPlanChanged(
+	stdout *planRecorder,
+	applied []*cbcli_cookbook.PlannedResource,
+	expectedHash string,
+) bool {
+	return applied == nil || planHash(stdout.output(), applied) != expectedHash
+}
+
+// returns an error if the cost of any resource could not
+// be estimated or if the estimated monthly cost of all the
+// target's resources exceeds the given maximum
+func checkMaxMonthlyCost(estimate *cbcli_cookbook.CostEstimate, maxMonthlyCost float64) error {
+	if len(estimate.Unpriced) > 0 {
+		return fmt.Errorf("the cost of %d of the target's resources could not be estimated", len(estimate.Unpriced))
+	}
+	if estimate.Total > maxMonthlyCost {
+		return fmt.Errorf(
+			"the estimated monthly cost of %.2f %s exceeds the maximum of %.2f %s",
+			estimate.Total, estimate.Currency, maxMonthlyCost, estimate.Currency,
+		)
+	}
+	return nil
+}
+
+// marks the target's instance resources and
+// optionally their data to be rebuilt on the
+// next launch
+func setRebuild(bldr *target.Builder, rebuild, cleanRebuild bool) error {
+
+	var (
+		err error
+	)
+
+	if cleanRebuild {
+		// mark target instance resource data to be
+		// rebuilt on next launch
+		if err = bldr.SetRebuildInstanceData(); err != nil {
+			return err
+		}
+	}
+	if cleanRebuild || rebuild {
+		// mark target instance resources to be
+		// rebuilt on next launch
+		if err = bldr.SetRebuildInstances(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creates a builder for the target and initializes the
+// target's launch context if it has not been initialized
+// or needs to be re-initialized
+func newTargetBuilder(tgt *target.Target, stdout, stderr io.Writer, forceInit bool) (*target.Builder, error) {
+
+	var (
+		err error
+
+		bldr *target.Builder
+	)
+
+	if bldr, err = tgt.NewBuilder(cbcli_config.Config.ContextVars(), stdout, stderr); err != nil {
+		return nil, err
+	}
+	if err = tgt.PrepareBackend(); err != nil {
+		// ensure backend state storage resources
+		// are created
+		return nil, err
+	}
+	if forceInit ||
+		tgt.CookbookVersion != tgt.Recipe.CookbookVersion() {
+		// force re-initializing
+		if err = bldr.Initialize(); err != nil {
+			return nil, err
+		}
+	} else {
+		// initialize if not initialized
+		if err = bldr.AutoInitialize(); err != nil {
+			return nil, err
+		}
+	}
+	return bldr, nil
+}
+
 func init() {
 	flags := launchCommand.Flags()
 	flags.SortFlags = false
@@ -151,4 +458,14 @@ func init() {
 		"re-build all instances and attached storage created by the launch recipe")
 	flags.BoolVarP(&launchFlags.plan, "plan", "p", false,
 		"show cloud resources to be created or changed, but do not launch")
+	flags.StringVar(&launchFlags.planOut, "plan-out", "",
+		"save the launch plan to the given file to be applied with\n'cb target apply', but do not launch")
+	flags.Float64Var(&launchFlags.maxMonthlyCost, "max-monthly-cost", 0,
+		"do not launch if the estimated monthly cost of the target once\nlaunched exceeds this amount or cannot be estimated")
+	flags.BoolVarP(&launchFlags.all, "all", "a", false,
+		"launch all configured targets")
+	flags.StringVar(&launchFlags.selector, "selector", "",
+		"launch the configured targets that match the selector\n(format <field>=<pattern>,...)")
+	flags.IntVarP(&launchFlags.parallel, "parallel", "P", 4,
+		"maximum number of targets to launch in parallel")
 }
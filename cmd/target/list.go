@@ -73,14 +73,14 @@ var spaceSelector = cbcli_utils.OptionSelector{
 		{
 			Text: " - Suspend",
 			Command: func(data interface{}) error {
-				SuspendTarget(data.(userspace.SpaceNode).Key())
+				SuspendTarget(data.(userspace.SpaceNode).Key(), nil)
 				return nil
 			},
 		},
 		{
 			Text: " - Resume",
 			Command: func(data interface{}) error {
-				ResumeTarget(data.(userspace.SpaceNode).Key())
+				ResumeTarget(data.(userspace.SpaceNode).Key(), nil)
 				return nil
 			},
 		},
@@ -123,6 +123,11 @@ func ListTargets() {
 		}
 	}
 
+	if !cbcli_utils.IsTableOutput() {
+		renderTargetsOutput(append(spacesRecipes, appsRecipes...))
+		return
+	}
+
 	targetIndex = 0
 	targetList := []*target.Target{}
 
@@ -151,6 +156,7 @@ func ListTargets() {
 			options[i] = strconv.Itoa(i + 1)
 		}
 		if response = cbcli_utils.GetUserInputFromList(
+			"select-target",
 			"Enter # of node to execute sub-command on or (q)uit: ",
 			"", options, false); response == "q" {
 			fmt.Println()
@@ -372,13 +378,62 @@ func buildAppsTable(
 	return table
 }
 
+func renderTargetsOutput(recipes []cookbook.CookbookRecipeInfo) {
+
+	output := []targetOutput{}
+	targets := cbcli_config.Config.TargetContext().TargetSet()
+
+	for _, recipe := range recipes {
+		for _, cloudProvider := range recipe.IaaSList {
+			for _, tgt := range targets.Lookup(recipe.RecipeKey, cloudProvider.Name()) {
+				output = append(output, newTargetOutput(tgt, false))
+			}
+		}
+	}
+	cbcli_utils.RenderOutput(output)
+}
+
+// returns all configured targets with the
+// space targets ordered before app targets
+func configuredTargets() []*target.Target {
+
+	var (
+		spacesRecipes,
+		appsRecipes []cookbook.CookbookRecipeInfo
+	)
+
+	for _, r := range cbcli_config.Config.TargetContext().Cookbook().RecipeList() {
+		if r.IsBastion {
+			spacesRecipes = append(spacesRecipes, r)
+		} else {
+			appsRecipes = append(appsRecipes, r)
+		}
+	}
+
+	tgts := []*target.Target{}
+	targets := cbcli_config.Config.TargetContext().TargetSet()
+	for _, recipe := range append(spacesRecipes, appsRecipes...) {
+		for _, cloudProvider := range recipe.IaaSList {
+			tgts = append(tgts, targets.Lookup(recipe.RecipeKey, cloudProvider.Name())...)
+		}
+	}
+	return tgts
+}
+
 func getTargetStatusName(tgt *target.Target) string {
 
 	var (
 		statusName string
 	)
 
-	switch tgt.Status() {
+	status := tgt.Status()
+	if status != target.Undeployed && isPartiallySuspended(tgt) {
+		return color.OpReverse.Render(
+			color.Magenta.Render("partially suspended"),
+		)
+	}
+
+	switch status {
 	case target.Undeployed:
 		statusName = "not deployed"
 	case target.Running:
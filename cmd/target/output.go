@@ -0,0 +1,120 @@
+package target
+
+import (
+	"time"
+
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/gocloud/cloud"
+
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+)
+
+// structured output schema for a target. the
+// schema is documented in doc/output.md and
+// fields should only be added to it.
+type targetOutput struct {
+	Key            string   `json:"key" yaml:"key"`
+	Type           string   `json:"type" yaml:"type"`
+	Recipe         string   `json:"recipe" yaml:"recipe"`
+	Cloud          string   `json:"cloud" yaml:"cloud"`
+	Region         string   `json:"region,omitempty" yaml:"region,omitempty"`
+	DeploymentName string   `json:"deploymentName" yaml:"deploymentName"`
+	Version        string   `json:"version" yaml:"version"`
+	Status         string   `json:"status" yaml:"status"`
+	Dependencies   []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Error          string   `json:"error,omitempty" yaml:"error,omitempty"`
+
+	Suspended *suspendedOutput `json:"suspended,omitempty" yaml:"suspended,omitempty"`
+
+	Instances []instanceOutput `json:"instances,omitempty" yaml:"instances,omitempty"`
+}
+
+// structured output schema for a
+// target's managed instance
+type instanceOutput struct {
+	Name     string `json:"name" yaml:"name"`
+	State    string `json:"state" yaml:"state"`
+	PublicIP string `json:"publicIP,omitempty" yaml:"publicIP,omitempty"`
+}
+
+// structured output schema for the
+// reason a target was suspended
+type suspendedOutput struct {
+	Reason string `json:"reason" yaml:"reason"`
+	At     string `json:"at" yaml:"at"`
+}
+
+func newTargetOutput(tgt *target.Target, withInstances bool) targetOutput {
+
+	var (
+		err error
+
+		state cloud.InstanceState
+	)
+
+	output := targetOutput{
+		Key:            tgt.Key(),
+		Recipe:         tgt.RecipeName,
+		Cloud:          tgt.RecipeIaas,
+		DeploymentName: tgt.DeploymentName(),
+		Version:        tgt.Version(),
+	}
+	if tgt.Recipe.IsBastion() {
+		output.Type = "space"
+	} else {
+		output.Type = "app"
+	}
+	if region := tgt.Provider.Region(); region != nil {
+		output.Region = *region
+	}
+	for _, dtgt := range tgt.Dependencies() {
+		output.Dependencies = append(output.Dependencies, dtgt.Key())
+	}
+
+	if tgt.Error() != nil {
+		output.Status = "error"
+		output.Error = tgt.Error().Error()
+		return output
+	}
+	output.Status = tgt.GetStatus()
+	if output.Status != "undeployed" && isPartiallySuspended(tgt) {
+		output.Status = partiallySuspended
+	}
+	if output.Status == "shutdown" {
+		if settings, err := cbcli_config.GetTargetSettings(tgt.Key()); err == nil && settings.Suspended != nil {
+			output.Suspended = &suspendedOutput{
+				Reason: settings.Suspended.Reason,
+				At:     settings.Suspended.At.Format(time.RFC3339),
+			}
+		}
+	}
+
+	if withInstances {
+		for _, managedInstance := range tgt.ManagedInstances() {
+			instance := instanceOutput{
+				Name:     managedInstance.Name(),
+				PublicIP: managedInstance.PublicIP(),
+			}
+			if state, err = managedInstance.State(); err == nil {
+				instance.State = instanceStateName(state)
+			} else {
+				instance.State = "unknown"
+			}
+			output.Instances = append(output.Instances, instance)
+		}
+	}
+	return output
+}
+
+func instanceStateName(state cloud.InstanceState) string {
+	switch state {
+	case cloud.StateRunning:
+		return "running"
+	case cloud.StateStopped:
+		return "stopped"
+	case cloud.StatePending:
+		return "pending"
+	default:
+		return "unknown"
+	}
+}
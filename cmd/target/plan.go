@@ -0,0 +1,303 @@
+package target
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+)
+
+// planRecorder is the output writer of a target
+// builder which records the output of a launch
+// plan so that the planned resources can be
+// determined from it
+type planRecorder struct {
+	out io.Writer
+
+	recording bool
+	echo      bool
+	plan      bytes.Buffer
+}
+
+// returns the recorded plan output
+// without any terminal color codes
+func (r *planRecorder) output() string {
+	return ansiEscape.ReplaceAllString(r.plan.String(), "")
+}
+
+func newPlanRecorder(out io.Writer) *planRecorder {
+	return &planRecorder{out: out}
+}
+
+func (r *planRecorder) Write(p []byte) (int, error) {
+	if r.recording {
+		r.plan.Write(p)
+		if !r.echo {
+			return len(p), nil
+		}
+	}
+	return r.out.Write(p)
+}
+
+// starts recording the builder's output. if echo
+// is false the output is only recorded.
+func (r *planRecorder) start(echo bool) {
+	r.plan.Reset()
+	r.recording = true
+	r.echo = echo
+}
+
+// stops recording and returns the resources
+// in the plan output that was recorded
+func (r *planRecorder) stop() ([]*cbcli_cookbook.PlannedResource, error) {
+	r.recording = false
+
+	output := r.output()
+	if !strings.Contains(output, "Plan:") && !strings.Contains(output, "No changes") {
+		return nil, fmt.Errorf("unable to determine the planned resources from the launch plan output")
+	}
+	return parsePlan(output), nil
+}
+
+var (
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	planResourceHeader = regexp.MustCompile(
+		`^\s*# (\S+) (?:\(\S+\) )?(will be created|will be destroyed|must be replaced|will be updated in-place)`)
+	planResourceBlock = regexp.MustCompile(
+		`^\s*(?:[-+~/]+\s+)?resource "([^"]+)" "[^"]+"\s*\{\s*$`)
+	planNestedBlock = regexp.MustCompile(
+		`^\s*(?:[-+~/]+\s+)?([A-Za-z0-9_]+)\s*\{\s*$`)
+	planExternalChangesStart = regexp.MustCompile(
+		`changed outside of Terraform`)
+	planExternalChangesEnd = regexp.MustCompile(
+		`^\s*(Unless you have made equivalent changes|This is a refresh-only plan|Terraform will perform the following actions|No changes\.|Plan:)`)
+	planExternalChangeHeader = regexp.MustCompile(
+		`^\s*# (\S+) (has changed|has been deleted)`)
+	planAttribute = regexp.MustCompile(
+		`^\s*([-+~]|-/\+|\+/-)?\s*([A-Za-z0-9_]+)\s*=\s*(.*?)\s*$`)
+)
+
+var planActions = map[string]string{
+	"will be created":          cbcli_cookbook.PlanCreate,
+	"will be destroyed":        cbcli_cookbook.PlanDestroy,
+	"must be replaced":         cbcli_cookbook.PlanReplace,
+	"will be updated in-place": cbcli_cookbook.PlanUpdate,
+}
+
+// parses the human readable output of a terraform plan
+// without color codes
+// and returns the resources it creates, changes or
+// destroys. only scalar attributes are retrieved and
+// the attributes of nested blocks are keyed by their
+// path (i.e. 'root_block_device.volume_size').
+func parsePlan(output string) []*cbcli_cookbook.PlannedResource {
+
+	var (
+		resource *cbcli_cookbook.PlannedResource
+		blocks   []string
+	)
+
+	resources := []*cbcli_cookbook.PlannedResource{}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := planResourceHeader.FindStringSubmatch(line); m != nil {
+			resource = &cbcli_cookbook.PlannedResource{
+				Address: m[1],
+				Action:  planActions[m[2]],
+				Before:  make(map[string]string),
+				After:   make(map[string]string),
+			}
+			blocks = nil
+			continue
+		}
+		if resource == nil {
+			continue
+		}
+
+		if blocks == nil {
+			if m := planResourceBlock.FindStringSubmatch(line); m != nil {
+				resource.Type = m[1]
+				blocks = []string{}
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case planNestedBlock.MatchString(line):
+			m := planNestedBlock.FindStringSubmatch(line)
+			blocks = append(blocks, m[1])
+
+		case trimmed == "}":
+			if len(blocks) == 0 {
+				// end of the resource block
+				switch resource.Action {
+				case cbcli_cookbook.PlanCreate:
+					resource.Before = nil
+				case cbcli_cookbook.PlanDestroy:
+					resource.After = nil
+				}
+				resources = append(resources, resource)
+				resource = nil
+			} else {
+				blocks = blocks[:len(blocks)-1]
+			}
+
+		case strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "["):
+			// nested values that are not blocks such as maps
+			// and lists are tracked so they are not confused
+			// with the resource's scalar attributes
+			blocks = append(blocks, "")
+
+		case trimmed == "]" || strings.HasPrefix(trimmed, "}") || strings.HasPrefix(trimmed, "]"):
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+
+		default:
+			if m := planAttribute.FindStringSubmatch(line); m != nil {
+				if len(blocks) > 0 && blocks[len(blocks)-1] == "" {
+					continue
+				}
+				name := strings.Join(append(append([]string{}, blocks...), m[2]), ".")
+				before, after := parsePlanValue(m[1], m[3])
+				if len(before) > 0 {
+					resource.Before[name] = before
+				}
+				if len(after) > 0 {
+					resource.After[name] = after
+				}
+			}
+		}
+	}
+	return resources
+}
+
+// returns the resources that the plan output reports as
+// having changed outside of terraform since the last
+// launch. only the section of the output that lists the
+// changes made outside of terraform is parsed. the
+// resources are mapped to the change that was made which
+// is either 'update' or 'destroy'.
+func parseExternalChanges(output string) map[string]string {
+
+	changes := make(map[string]string)
+
+	inSection := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case planExternalChangesStart.MatchString(line):
+			inSection = true
+		case planExternalChangesEnd.MatchString(line):
+			inSection = false
+		case inSection:
+			if m := planExternalChangeHeader.FindStringSubmatch(line); m != nil {
+				if m[2] == "has been deleted" {
+					changes[m[1]] = cbcli_cookbook.PlanDestroy
+				} else {
+					changes[m[1]] = cbcli_cookbook.PlanUpdate
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// returns the values of an attribute before and
+// after the plan is applied given its change
+// marker and value in the plan output
+func parsePlanValue(marker, value string) (string, string) {
+
+	// strip trailing annotations
+	if i := strings.Index(value, " # "); i >= 0 {
+		value = value[:i]
+	}
+
+	before, after := value, value
+	if i := strings.Index(value, " -> "); i >= 0 {
+		before, after = value[:i], value[i+4:]
+	}
+	switch marker {
+	case "+":
+		before = ""
+	case "-":
+		after = ""
+	}
+	return unquotePlanValue(before), unquotePlanValue(after)
+}
+
+func unquotePlanValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "null" {
+		return ""
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// returns a hash of the actions of a launch plan
+// given its output and planned resources
+func planHash(output string, resources []*cbcli_cookbook.PlannedResource) string {
+
+	sorted := append([]*cbcli_cookbook.PlannedResource{}, resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Address < sorted[j].Address
+	})
+
+	h := sha256.New()
+	hashValues := func(prefix string, values map[string]string) {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			writeHashField(h, prefix+name, values[name])
+		}
+	}
+	for _, resource := range sorted {
+		writeHashField(h, "resource", resource.Address)
+		writeHashField(h, "type", resource.Type)
+		writeHashField(h, "action", resource.Action)
+		hashValues("before.", resource.Before)
+		hashValues("after.", resource.After)
+	}
+
+	// the resources only include scalar attributes so the
+	// section of the output that describes all the actions
+	// is also hashed. the output preceding this section
+	// may vary as it logs the progress of the plan.
+	if i := strings.Index(output, "Terraform will perform the following actions"); i >= 0 {
+		actions := output[i:]
+		if j := strings.Index(actions, "\nPlan:"); j >= 0 {
+			if k := strings.Index(actions[j+1:], "\n"); k >= 0 {
+				actions = actions[:j+1+k]
+			}
+		}
+		writeHashField(h, "actions", actions)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writes a length prefixed name and value to the hash
+// so that different fields cannot have the same hash
+func writeHashField(h hash.Hash, name, value string) {
+	fmt.Fprintf(h, "%d:%s=%d:%s\n", len(name), name, len(value), value)
+}
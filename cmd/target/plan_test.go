@@ -0,0 +1,300 @@
+package target
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	cbcli_cookbook "github.com/appbricks/cloud-builder-cli/cookbook"
+)
+
+// terraform plan output with color codes as
+// written by the target builder when launching
+const planFixture = "\x1b[0mTerraform used the selected providers to generate the following execution\n" +
+	`plan. Resource actions are indicated with the following symbols:
+  + create
+  ~ update in-place
+  - destroy
+-/+ destroy and then create replacement
+
+Terraform will perform the following actions:
+
+  # aws_instance.bastion will be created
+  + resource "aws_instance" "bastion" {
+      + ami                          = "ami-0abcdef1234567890"
+      + arn                          = (known after apply)
+      + instance_type                = "t3.micro"
+      + tags                         = {
+          + "Name" = "bastion"
+        }
+      + vpc_security_group_ids       = [
+          + "sg-12345678",
+        ]
+
+      + root_block_device {
+          + delete_on_termination = true
+          + volume_size           = 20
+          + volume_type           = "gp3"
+        }
+    }
+
+  # aws_instance.app will be updated in-place
+  ~ resource "aws_instance" "app" {
+        id                           = "i-0123456789abcdef0"
+      ~ instance_type                = "t3.small" -> "t3.medium"
+        tags                         = {
+            "Name" = "app"
+        }
+        # (28 unchanged attributes hidden)
+
+      ~ root_block_device {
+          ~ volume_size           = 20 -> 50
+            # (6 unchanged attributes hidden)
+        }
+
+        # (4 unchanged blocks hidden)
+    }
+
+  # aws_eip.app must be replaced
+-/+ resource "aws_eip" "app" {
+      ~ allocation_id        = "eipalloc-0123456789abcdef0" -> (known after apply)
+      ~ id                   = "eipalloc-0123456789abcdef0" -> (known after apply)
+      ~ instance             = "i-0aaaaaaaaaaaaaaaa" -> "i-0bbbbbbbbbbbbbbbb" # forces replacement
+      ~ public_ip            = "3.4.5.6" -> (known after apply)
+        vpc                  = true
+    }
+
+  # aws_ebs_volume.data will be destroyed
+  - resource "aws_ebs_volume" "data" {
+      - availability_zone = "us-east-1a" -> null
+      - id                = "vol-0123456789abcdef0" -> null
+      - size              = 100 -> null
+      - type              = "gp3" -> null
+    }
+
+  # module.network.aws_nat_gateway.nat[0] will be created
+  + resource "aws_nat_gateway" "nat" {
+      + allocation_id = "eipalloc-0fedcba9876543210"
+      + id            = (known after apply)
+    }
+
+` + "\x1b[1mPlan:\x1b[0m 2 to add, 1 to change, 1 to destroy.\n"
+
+func findPlannedResource(resources []*cbcli_cookbook.PlannedResource, address string) *cbcli_cookbook.PlannedResource {
+	for _, r := range resources {
+		if r.Address == address {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestParsePlan(t *testing.T) {
+
+	recorder := newPlanRecorder(&strings.Builder{})
+	recorder.start(false)
+	if _, err := recorder.Write([]byte(planFixture)); err != nil {
+		t.Fatalf("unable to record plan: %s", err.Error())
+	}
+	resources, err := recorder.stop()
+	if err != nil {
+		t.Fatalf("unable to parse plan: %s", err.Error())
+	}
+	if len(resources) != 5 {
+		t.Fatalf("expected 5 planned resources but got %d", len(resources))
+	}
+
+	expected := []*cbcli_cookbook.PlannedResource{
+		{
+			Address: "aws_instance.bastion",
+			Type:    "aws_instance",
+			Action:  cbcli_cookbook.PlanCreate,
+			After: map[string]string{
+				"ami":           "ami-0abcdef1234567890",
+				"arn":           "(known after apply)",
+				"instance_type": "t3.micro",
+				"root_block_device.delete_on_termination": "true",
+				"root_block_device.volume_size":           "20",
+				"root_block_device.volume_type":           "gp3",
+			},
+		},
+		{
+			Address: "aws_instance.app",
+			Type:    "aws_instance",
+			Action:  cbcli_cookbook.PlanUpdate,
+			Before: map[string]string{
+				"id":                            "i-0123456789abcdef0",
+				"instance_type":                 "t3.small",
+				"root_block_device.volume_size": "20",
+			},
+			After: map[string]string{
+				"id":                            "i-0123456789abcdef0",
+				"instance_type":                 "t3.medium",
+				"root_block_device.volume_size": "50",
+			},
+		},
+		{
+			Address: "aws_eip.app",
+			Type:    "aws_eip",
+			Action:  cbcli_cookbook.PlanReplace,
+			Before: map[string]string{
+				"allocation_id": "eipalloc-0123456789abcdef0",
+				"id":            "eipalloc-0123456789abcdef0",
+				"instance":      "i-0aaaaaaaaaaaaaaaa",
+				"public_ip":     "3.4.5.6",
+				"vpc":           "true",
+			},
+			After: map[string]string{
+				"allocation_id": "(known after apply)",
+				"id":            "(known after apply)",
+				"instance":      "i-0bbbbbbbbbbbbbbbb",
+				"public_ip":     "(known after apply)",
+				"vpc":           "true",
+			},
+		},
+		{
+			Address: "aws_ebs_volume.data",
+			Type:    "aws_ebs_volume",
+			Action:  cbcli_cookbook.PlanDestroy,
+			Before: map[string]string{
+				"availability_zone": "us-east-1a",
+				"id":                "vol-0123456789abcdef0",
+				"size":              "100",
+				"type":              "gp3",
+			},
+		},
+		{
+			Address: "module.network.aws_nat_gateway.nat[0]",
+			Type:    "aws_nat_gateway",
+			Action:  cbcli_cookbook.PlanCreate,
+			After: map[string]string{
+				"allocation_id": "eipalloc-0fedcba9876543210",
+				"id":            "(known after apply)",
+			},
+		},
+	}
+	for _, e := range expected {
+		r := findPlannedResource(resources, e.Address)
+		if r == nil {
+			t.Errorf("resource '%s' was not found in the parsed plan", e.Address)
+			continue
+		}
+		if r.Type != e.Type || r.Action != e.Action {
+			t.Errorf("resource '%s': expected type '%s' and action '%s' but got '%s' and '%s'",
+				e.Address, e.Type, e.Action, r.Type, r.Action)
+		}
+		if !reflect.DeepEqual(r.Before, e.Before) {
+			t.Errorf("resource '%s': expected before values %v but got %v", e.Address, e.Before, r.Before)
+		}
+		if !reflect.DeepEqual(r.After, e.After) {
+			t.Errorf("resource '%s': expected after values %v but got %v", e.Address, e.After, r.After)
+		}
+	}
+}
+
+func TestParsePlanNoChanges(t *testing.T) {
+
+	recorder := newPlanRecorder(&strings.Builder{})
+	recorder.start(false)
+	_, _ = recorder.Write([]byte(
+		"\x1b[0m\x1b[1m\x1b[32mNo changes.\x1b[0m\x1b[1m Your infrastructure matches the configuration.\x1b[0m\n",
+	))
+	resources, err := recorder.stop()
+	if err != nil {
+		t.Fatalf("unable to parse plan: %s", err.Error())
+	}
+	if len(resources) != 0 {
+		t.Fatalf("expected no planned resources but got %d", len(resources))
+	}
+}
+
+func TestParsePlanUnrecognizedOutput(t *testing.T) {
+
+	recorder := newPlanRecorder(&strings.Builder{})
+	recorder.start(false)
+	_, _ = recorder.Write([]byte("Error: Invalid provider configuration\n"))
+	if _, err := recorder.stop(); err == nil {
+		t.Fatal("expected an error for output that is not a plan")
+	}
+}
+
+func TestPlanRecorderEcho(t *testing.T) {
+
+	out := &strings.Builder{}
+	recorder := newPlanRecorder(out)
+
+	_, _ = recorder.Write([]byte("before "))
+	recorder.start(false)
+	_, _ = recorder.Write([]byte("hidden "))
+	recorder.start(true)
+	_, _ = recorder.Write([]byte("shown "))
+	_, _ = recorder.stop()
+	_, _ = recorder.Write([]byte("after"))
+
+	if out.String() != "before shown after" {
+		t.Fatalf("unexpected output '%s'", out.String())
+	}
+}
+
+func TestParseExternalChanges(t *testing.T) {
+
+	output := `
+Note: Objects have changed outside of Terraform
+
+Terraform detected the following changes made outside of Terraform since the
+last "terraform apply" which may have affected this plan:
+
+  # aws_instance.app has changed
+  ~ resource "aws_instance" "app" {
+        id            = "i-0123456789abcdef0"
+      ~ instance_type = "t3.small" -> "t3.large"
+        # (28 unchanged attributes hidden)
+    }
+
+  # aws_ebs_volume.data has been deleted
+  - resource "aws_ebs_volume" "data" {
+      - id = "vol-0123456789abcdef0" -> null
+    }
+
+Unless you have made equivalent changes to your configuration, or ignored the
+relevant attributes using ignore_changes, the following plan may include
+actions to undo or respond to these changes.
+
+Terraform will perform the following actions:
+
+  # aws_ebs_volume.data will be created
+  + resource "aws_ebs_volume" "data" {
+      + size = 100
+    }
+
+  # aws_instance.app will be updated in-place
+  ~ resource "aws_instance" "app" {
+      ~ instance_type = "t3.large" -> "t3.small"
+    }
+
+  # aws_s3_bucket.logs will be destroyed
+  - resource "aws_s3_bucket" "logs" {
+      - bucket = "logs" -> null
+    }
+
+  # aws_eip.app has changed
+  ~ resource "aws_eip" "app" {
+    }
+
+Plan: 1 to add, 1 to change, 1 to destroy.
+`
+	changes := parseExternalChanges(output)
+	expected := map[string]string{
+		"aws_instance.app":    cbcli_cookbook.PlanUpdate,
+		"aws_ebs_volume.data": cbcli_cookbook.PlanDestroy,
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected external changes %v but got %v", expected, changes)
+	}
+
+	// changes to the configuration are not reported
+	// as changes made outside of terraform
+	if changes = parseExternalChanges(planFixture); len(changes) != 0 {
+		t.Fatalf("expected no external changes but got %v", changes)
+	}
+}
@@ -0,0 +1,320 @@
+package target
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/gookit/color"
+	"github.com/mevansam/goutils/logger"
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/appbricks/mycloudspace-client/api"
+	"github.com/appbricks/mycloudspace-client/mycscloud"
+	"github.com/mevansam/goforms/forms"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var renameFlags = struct {
+	commonFlags
+}{}
+
+var renameCommand = &cobra.Command{
+	Use: "rename [recipe] [cloud] [deployment name] [new deployment name]",
+
+	Short: "Change the deployment name of a target.",
+	Long: `
+Changes the deployment name of a target without deleting and
+re-creating it. Applications attached to a space that is renamed are
+updated to refer to the space by its new name and the target's
+registration is replaced with one for the new name.
+
+The deployment state of a deployed target is moved to the backend
+location of the new name. The state is copied by re-initializing the
+target's launch context with the new location and the copy is
+verified by reading the deployment's outputs from the new location
+before the state at the previous location is deleted. If the copy
+cannot be verified the target is not renamed. Cloud resources named
+after the deployment name are only renamed when the target is next
+launched.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		RenameTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(renameFlags.commonFlags)), args[3])
+	},
+	Args: cobra.ExactArgs(4),
+}
+
+func RenameTarget(targetKey, newName string) {
+
+	var (
+		err error
+
+		tgt        *target.Target
+		dependents []*target.Target
+	)
+	config := cbcli_config.Config
+	context := config.TargetContext()
+
+	if tgt, err = context.GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+	oldName := tgt.DeploymentName()
+	if newName == oldName {
+		cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Target \"%s\" already has that name.", oldName))
+	}
+	deployed := tgt.Status() != target.Undeployed
+
+	if deployed {
+		fmt.Println()
+		cbcli_utils.ShowNoticeMessage(
+			"Target \"%s\" has been deployed. Its deployment state will be moved to the backend location of the new name.",
+			oldName,
+		)
+		fmt.Println()
+		if !cbcli_utils.GetConfirmationInput(
+			"confirm-rename-target",
+			"Confirm rename by entering the deployment name: ",
+			oldName,
+		) {
+			fmt.Print(color.Red.Render("\nTarget has not been renamed.\n\n"))
+			return
+		}
+	}
+
+	// applications attached to a space are keyed by
+	// the space's key so they need to be re-keyed
+	for _, ctgt := range configuredTargets() {
+		for _, dkey := range ctgt.DependentTargets {
+			if dkey == targetKey {
+				dependents = append(dependents, ctgt)
+				break
+			}
+		}
+	}
+
+	// a deployed target is renamed as a copy so that
+	// the target with the old name locates the
+	// deployment state until it has been moved
+	oldTgt := tgt
+	if deployed {
+		if tgt, err = context.GetTarget(targetKey); err != nil || tgt == nil || tgt == oldTgt {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf("Unable to copy the configuration of target \"%s\".", oldName))
+		}
+	}
+	if err = setDeploymentName(tgt, newName); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	newKey := tgt.Key()
+	if context.HasTarget(newKey) {
+		cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Target \"%s\" already exists.", newKey))
+	}
+
+	// the backend storage of the target
+	// is named after the deployment name
+	if tgt.Backend != nil {
+		if err = tgt.Backend.Configure(
+			tgt.Provider,
+			tgt.DeploymentName(), tgt.RecipeName,
+		); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+	if deployed && tgt.Backend != nil {
+		if err = moveDeploymentState(oldTgt, tgt); err != nil {
+			cbcli_utils.ShowErrorAndExit(
+				fmt.Sprintf(
+					"Unable to move the deployment state of target \"%s\" to the backend location of the new name: %s. The target has not been renamed",
+					oldName, err.Error(),
+				),
+			)
+		}
+	}
+
+	// the target is removed from its old key only
+	// after its dependents have been re-keyed
+	context.SaveTarget(newKey, tgt)
+	for _, dtgt := range dependents {
+		dkey := dtgt.Key()
+		for i, key := range dtgt.DependentTargets {
+			if key == targetKey {
+				dtgt.DependentTargets[i] = newKey
+			}
+		}
+		context.SaveTarget(dtgt.Key(), dtgt)
+		context.DeleteTarget(dkey)
+		moveTargetSettings(dkey, dtgt.Key())
+	}
+	context.DeleteTarget(targetKey)
+	moveTargetSettings(targetKey, newKey)
+
+	// the state at the previous location is only
+	// deleted once its copy has been verified
+	if deployed && oldTgt.Backend != nil {
+		if err = oldTgt.DeleteBackend(); err != nil {
+			logger.ErrorMessage("RenameTarget(): Error deleting target's previous deployment state remote storage: %s", err.Error())
+			cbcli_utils.ShowNoteMessage("\nDeleting the previous deployment state remote storage of the target failed. You need to delete it manually from your cloud provider console.")
+		}
+	}
+
+	// replace the target's registration
+	// in the MyCS account
+	if tgt.Recipe.IsBastion() {
+		spaceAPI := mycscloud.NewSpaceAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if _, err = spaceAPI.DeleteSpace(tgt); err == nil {
+			err = spaceAPI.AddSpace(tgt, true)
+		}
+		if err != nil {
+			logger.ErrorMessage("RenameTarget(): Error attempting to update space registration: %s", err.Error())
+			cbcli_utils.ShowNoteMessage("\nUpdating the space registration failed. You may need to update the space from the MyCS cloud dashboard.")
+		}
+
+	} else {
+		var spaceTgt *target.Target
+		if deps := tgt.Dependencies(); len(deps) > 0 {
+			spaceTgt = deps[0]
+		}
+		appAPI := mycscloud.NewAppAPI(api.NewGraphQLClient(cbcli_config.AWS_USERSPACE_API_URL, "", config))
+		if _, err = appAPI.DeleteApp(tgt); err == nil && spaceTgt != nil {
+			err = appAPI.AddApp(tgt, spaceTgt.GetSpaceID())
+		}
+		if err != nil {
+			logger.ErrorMessage("RenameTarget(): Error attempting to update app registration: %s", err.Error())
+			cbcli_utils.ShowNoteMessage("\nUpdating the app registration failed. You may need to update the app from the MyCS cloud dashboard.")
+		}
+	}
+
+	fmt.Print(color.Green.Render(fmt.Sprintf("\nTarget \"%s\" has been renamed to \"%s\".\n\n", oldName, newName)))
+}
+
+// sets the deployment name input of
+// the target's recipe to the given name
+func setDeploymentName(tgt *target.Target, name string) error {
+
+	var (
+		err error
+
+		recipeInputForm forms.InputForm
+		nameField       *forms.InputField
+	)
+
+	if recipeInputForm, err = tgt.Recipe.InputForm(); err != nil {
+		return err
+	}
+	if nameField, err = recipeInputForm.GetInputField("name"); err != nil {
+		return fmt.Errorf("Recipe '%s' does not have a deployment name input.", tgt.RecipeName)
+	}
+	if err = nameField.SetValue(&name); err != nil {
+		return fmt.Errorf("Invalid deployment name '%s': %s", name, err.Error())
+	}
+	return nil
+}
+
+// moves the deployment state of the target to the backend
+// location of the renamed target. the state is copied by
+// re-initializing the target's launch context with the new
+// backend location which migrates the state to it. the copy
+// is verified by initializing the renamed target's launch
+// context and comparing the outputs of the deployment read
+// from the new location with those read from the previous
+// location. if the copy cannot be verified the target's
+// launch context is re-initialized with the previous
+// location whose state is not changed.
+func moveDeploymentState(tgt, renamedTgt *target.Target) error {
+
+	var (
+		err error
+
+		migrateTgt *target.Target
+		bldr       *target.Builder
+	)
+	contextVars := cbcli_config.Config.ContextVars()
+
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(" Moving the deployment state of the target to the backend location of the new name."),
+		spinner.WithFinalMSG(""),
+		spinner.WithHiddenCursor(true),
+	)
+	s.Start()
+	defer s.Stop()
+
+	// read the deployment's outputs
+	// from the previous location
+	if bldr, err = tgt.NewBuilder(contextVars, io.Discard, os.Stderr); err != nil {
+		return err
+	}
+	if err = bldr.AutoInitialize(); err != nil {
+		return err
+	}
+	output := bldr.Output()
+	if output == nil {
+		return fmt.Errorf("the deployment state could not be read from the previous location")
+	}
+
+	// copy the state by re-initializing the
+	// target's launch context with the new
+	// location once its storage has been created
+	if err = renamedTgt.PrepareBackend(); err != nil {
+		return err
+	}
+	if migrateTgt, err = cbcli_config.Config.TargetContext().GetTarget(tgt.Key()); err != nil || migrateTgt == tgt {
+		return fmt.Errorf("unable to copy the configuration of the target")
+	}
+	if err = migrateTgt.Backend.Configure(
+		migrateTgt.Provider,
+		renamedTgt.DeploymentName(), migrateTgt.RecipeName,
+	); err != nil {
+		return err
+	}
+	if bldr, err = migrateTgt.NewBuilder(contextVars, io.Discard, os.Stderr); err == nil {
+		if err = bldr.Initialize(); err == nil {
+			// verify the copy from the renamed
+			// target's launch context
+			if bldr, err = renamedTgt.NewBuilder(contextVars, io.Discard, os.Stderr); err == nil {
+				if err = bldr.Initialize(); err == nil && !reflect.DeepEqual(output, bldr.Output()) {
+					err = fmt.Errorf("the deployment state read from the new location does not match the state at the previous location")
+				}
+			}
+		}
+	}
+	if err != nil {
+		// restore the target's launch
+		// context to the previous location
+		if bldr, restoreErr := tgt.NewBuilder(contextVars, io.Discard, os.Stderr); restoreErr != nil || bldr.Initialize() != nil {
+			logger.ErrorMessage("moveDeploymentState(): Unable to re-initialize the target's launch context with the previous backend location.")
+			err = fmt.Errorf("%s. Run 'cb target launch --init' to re-initialize the target's launch context", err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func moveTargetSettings(oldKey, newKey string) {
+	if err := cbcli_config.RenameTargetSettings(oldKey, newKey); err != nil {
+		logger.ErrorMessage("RenameTarget(): Error moving target's settings: %s", err.Error())
+	}
+}
+
+func init() {
+	flags := renameCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(renameFlags.commonFlags))
+}
@@ -20,7 +20,7 @@ import (
 var resumeFlags = struct {
 	commonFlags
 
-	instance string
+	instances []string
 }{}
 
 var resumeCommand = &cobra.Command{
@@ -29,65 +29,36 @@ var resumeCommand = &cobra.Command{
 	Short: "Resumes a suspended target.",
 	Long: `
 This sub-command resumes all instances deployed to a target. To
-resume a specific instance provide the instance name via the 
-'-i|--instance' option.
+resume specific instances provide the instance names via the 
+'-i|--instance' option. The option can be repeated or given a comma
+separated list of names. Instances are started in the order they
+were deployed in.
 `,
 
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		ResumeTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(resumeFlags.commonFlags)))
+		ResumeTarget(
+			getTargetKeyFromArgs(args[0], args[1], args[2], &(resumeFlags.commonFlags)),
+			resumeFlags.instances,
+		)
 	},
 	Args: cobra.ExactArgs(3),
 }
 
-func ResumeTarget(targetKey string) {
+func ResumeTarget(targetKey string, instanceNames []string) {
 
 	var (
 		err error
 
 		tgt *target.Target
-		s   *spinner.Spinner
 	)
 
 	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err == nil && tgt != nil {
 
-		if tgt.Status() == target.Shutdown {
-			fmt.Println()
-			if err = tgt.Resume(
-				func(name string, instance *target.ManagedInstance) {
-					state, _ := instance.State()
-					if state == cloud.StateStopped {
-						s = spinner.New(
-							spinner.CharSets[cbcli_config.SpinnerNetworkType], 
-							100*time.Millisecond,
-							spinner.WithSuffix(fmt.Sprintf(" Starting instance \"%s\".", name)),
-							spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" started.\n\n", name)),
-							spinner.WithHiddenCursor(true),
-						)
-						s.Start()	
-						
-					} else if len(instance.PublicIP()) > 0 {
-						for {
-							ok, err := instance.CanConnect()
-							if ok || err != nil {
-								if err != nil {
-									logger.ErrorMessage(err.Error())
-								}
-								break
-							}
-							time.Sleep(time.Second * 5)
-						}
-						s.Stop()
-					} else {
-						s.Stop()
-					}
-				},
-			); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
-		} else {
-			cbcli_utils.ShowErrorAndExit("target needs to be 'shutdown' to be resumed")
+		fmt.Println()
+		if err = ResumeTargetInstances(tgt, instanceNames); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
 		return
 	}
@@ -100,10 +71,79 @@ func ResumeTarget(targetKey string) {
 	)
 }
 
+// resumes the target's instances with the given names or
+// all of the target's instances if no names are given
+func ResumeTargetInstances(tgt *target.Target, instanceNames []string) error {
+
+	var (
+		err error
+
+		s *spinner.Spinner
+
+		instances []*target.ManagedInstance
+	)
+
+	if len(instanceNames) > 0 || isPartiallySuspended(tgt) {
+		if tgt.Status() == target.Undeployed {
+			return fmt.Errorf("target needs to be deployed to resume its instances")
+		}
+		if instances, err = selectManagedInstances(tgt, instanceNames); err != nil {
+			return err
+		}
+		if err = resumeInstances(instances); err != nil {
+			return err
+		}
+		recordSuspendedInstances(tgt, instances, false)
+
+	} else if tgt.Status() != target.Shutdown {
+		return fmt.Errorf("target needs to be 'shutdown' to be resumed")
+
+	} else if err = tgt.Resume(
+		func(name string, instance *target.ManagedInstance) {
+			state, _ := instance.State()
+			if state == cloud.StateStopped {
+				s = spinner.New(
+					spinner.CharSets[cbcli_config.SpinnerNetworkType], 
+					100*time.Millisecond,
+					spinner.WithSuffix(fmt.Sprintf(" Starting instance \"%s\".", name)),
+					spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" started.\n\n", name)),
+					spinner.WithHiddenCursor(true),
+				)
+				s.Start()	
+				
+			} else if len(instance.PublicIP()) > 0 {
+				for {
+					ok, err := instance.CanConnect()
+					if ok || err != nil {
+						if err != nil {
+							logger.ErrorMessage(err.Error())
+						}
+						break
+					}
+					time.Sleep(time.Second * 5)
+				}
+				s.Stop()
+			} else {
+				s.Stop()
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	if len(instanceNames) == 0 {
+		recordSuspendedInstances(tgt, tgt.ManagedInstances(), false)
+	}
+	if !isPartiallySuspended(tgt) {
+		clearSuspension(tgt)
+	}
+	return nil
+}
+
 func init() {
 	flags := resumeCommand.Flags()
 	flags.SortFlags = false
 	bindCommonFlags(flags, &(resumeFlags.commonFlags))
 
-	flags.StringVarP(&resumeFlags.instance, "instance", "i", "", "name of the instance to resume")
+	flags.StringSliceVarP(&resumeFlags.instances, "instance", "i", []string{}, "name of an instance to resume")
 }
@@ -0,0 +1,173 @@
+package target
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var scheduleFlags = struct {
+	commonFlags
+
+	suspend  string
+	resume   string
+	timezone string
+	clear    bool
+}{}
+
+var scheduleCommand = &cobra.Command{
+	Use: "schedule [recipe] [cloud] [deployment name]",
+
+	Short: "Schedule when a target is suspended and resumed.",
+	Long: `
+Attaches cron style suspend and resume windows to a target. Each
+window is a standard 5 field cron expression (minute, hour, day of
+month, month and day of week) or a descriptor such as '@daily' which
+is evaluated in the given timezone. The local timezone is used if a
+timezone is not provided. For example the following schedule will
+suspend a target at 10pm and resume it at 7am on weekdays.
+
+  cb target schedule vpn aws mytarget \
+    --suspend "0 22 * * 1-5" --resume "0 7 * * 1-5" \
+    --timezone America/New_York
+
+Schedules are evaluated by 'cb scheduler run'. A schedule is saved
+with the target's settings on this device and is not synced to your
+other devices, so only the scheduler running on this device acts on
+it. Run this sub-command without any options to view the target's
+current schedule.
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ScheduleTarget(cmd, getTargetKeyFromArgs(args[0], args[1], args[2], &(scheduleFlags.commonFlags)))
+	},
+	Args: cobra.ExactArgs(3),
+}
+
+func ScheduleTarget(cmd *cobra.Command, targetKey string) {
+
+	var (
+		err error
+
+		tgt      *target.Target
+		settings *cbcli_config.TargetSettings
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+	if settings, err = cbcli_config.GetTargetSettings(tgt.Key()); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	flags := cmd.Flags()
+	if scheduleFlags.clear {
+		if flags.Changed("suspend") || flags.Changed("resume") || flags.Changed("timezone") {
+			cbcli_utils.ShowErrorAndExit("The '--clear' option cannot be combined with other schedule options.")
+		}
+		settings.Schedule = nil
+		if err = cbcli_config.UpdateTargetSettings(tgt.Key(), func(s *cbcli_config.TargetSettings) error {
+			s.Schedule = settings.Schedule
+			return nil
+		}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		fmt.Println()
+		cbcli_utils.ShowInfoMessage("The schedule of target \"%s\" has been removed.", tgt.DeploymentName())
+		fmt.Println()
+		return
+	}
+
+	if flags.Changed("suspend") || flags.Changed("resume") || flags.Changed("timezone") {
+		schedule := settings.Schedule
+		if schedule == nil {
+			schedule = &cbcli_config.TargetSchedule{}
+		}
+		if flags.Changed("suspend") {
+			schedule.Suspend = scheduleFlags.suspend
+		}
+		if flags.Changed("resume") {
+			schedule.Resume = scheduleFlags.resume
+		}
+		if flags.Changed("timezone") {
+			schedule.Timezone = scheduleFlags.timezone
+		}
+		if err = schedule.Validate(); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+		// windows prior to the change of
+		// schedule should not be caught up on
+		schedule.LastEvaluated = time.Now()
+
+		settings.Schedule = schedule
+		if err = cbcli_config.UpdateTargetSettings(tgt.Key(), func(s *cbcli_config.TargetSettings) error {
+			s.Schedule = settings.Schedule
+			return nil
+		}); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	fmt.Println()
+	if settings.Schedule == nil {
+		cbcli_utils.ShowNoticeMessage("Target \"%s\" does not have a schedule.", tgt.DeploymentName())
+	} else {
+		showSchedule(settings.Schedule)
+	}
+	fmt.Println()
+}
+
+func showSchedule(schedule *cbcli_config.TargetSchedule) {
+
+	var (
+		err error
+
+		nextSuspend, nextResume time.Time
+	)
+
+	timezone := schedule.Timezone
+	if len(timezone) == 0 {
+		timezone = "local"
+	}
+	if nextSuspend, nextResume, err = schedule.Next(time.Now()); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	fmt.Printf("Timezone: %s\n", timezone)
+	if len(schedule.Suspend) > 0 {
+		fmt.Printf("Suspend:  %s (next at %s)\n", schedule.Suspend, nextSuspend.Format(time.RFC1123))
+	}
+	if len(schedule.Resume) > 0 {
+		fmt.Printf("Resume:   %s (next at %s)\n", schedule.Resume, nextResume.Format(time.RFC1123))
+	}
+}
+
+func init() {
+	flags := scheduleCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(scheduleFlags.commonFlags))
+
+	flags.StringVar(&scheduleFlags.suspend, "suspend", "",
+		"cron expression of the windows in which to suspend the target")
+	flags.StringVar(&scheduleFlags.resume, "resume", "",
+		"cron expression of the windows in which to resume the target")
+	flags.StringVarP(&scheduleFlags.timezone, "timezone", "z", "",
+		"timezone in which the schedule is evaluated (i.e. America/New_York)")
+	flags.BoolVar(&scheduleFlags.clear, "clear", false,
+		"remove the target's schedule")
+}
@@ -3,6 +3,7 @@ package target
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
@@ -57,6 +58,10 @@ func ShowTarget(targetKey string) {
 
 	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err == nil && tgt != nil {
 
+		if !cbcli_utils.IsTableOutput() {
+			cbcli_utils.RenderOutput(newTargetOutput(tgt, true))
+			return
+		}
 		showNodeInfo(tgt)
 
 		if showFlags.config || showFlags.all {
@@ -120,6 +125,20 @@ func showNodeInfo(tgt *target.Target) {
 	fmt.Print("\nStatus: ")
 	fmt.Println(getTargetStatusName(tgt))
 
+	if settings, err := cbcli_config.GetTargetSettings(tgt.Key()); err == nil {
+		if settings.Suspended != nil && tgt.Status() == target.Shutdown {
+			fmt.Printf(
+				"\nSuspended: %s (at %s)\n",
+				settings.Suspended.Reason,
+				settings.Suspended.At.Local().Format(time.RFC1123),
+			)
+		}
+		if settings.Schedule != nil {
+			fmt.Println("\nSchedule:")
+			showSchedule(settings.Schedule)
+		}
+	}
+
 	fmt.Println()
 	fmt.Print(tgt.Description())
 
@@ -3,8 +3,10 @@ package target
 import (
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/gookit/color"
 	"github.com/spf13/cobra"
@@ -16,7 +18,6 @@ import (
 	"github.com/mevansam/gocloud/cloud"
 	"github.com/mevansam/goutils/logger"
 	"github.com/mevansam/goutils/streams"
-	"github.com/mevansam/goutils/utils"
 
 	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
 	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
@@ -26,7 +27,8 @@ import (
 var sshFlags = struct {
 	commonFlags
 
-	sudo bool
+	sudo         bool
+	resetHostKey bool
 }{}
 
 var sshCommand = &cobra.Command{
@@ -37,10 +39,18 @@ var sshCommand = &cobra.Command{
 Create an SSH session to one of the target's running instance
 resources. This sub-command is available for advance users as well as
 for troubleshooting any configuration errors at the target. This sub-
-command can be run only on instances that are running, have a public
-IP and allow SSH ingress from the internet. If the instance is
-internal then this command can only be run once the VPN connection to
-the cloud space sandbox VPN has been establised.
+command can be run only on instances that are running. Instances that
+do not have a public IP are reached by jumping through the bastion
+instance of the target's space, so a VPN connection to the space is
+not required.
+
+The host key of each instance is recorded the first time it is
+connected to, unless the target's recipe publishes it, and connections
+to an instance whose host key has changed are refused. Connections are
+also refused if the host key published by the recipe is invalid. If an
+instance has been rebuilt provide the '--reset-host-key' option to
+discard the recorded host key. Recorded host keys are saved encrypted
+with the rest of the target's settings.
 `,
 
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
@@ -60,7 +70,7 @@ func SSHTarget(targetKey string) {
 
 		tgt    *target.Target
 		state  cloud.InstanceState
-		client *utils.SSHClient
+		client *instanceConnection
 
 		managedInstance *target.ManagedInstance
 		instanceIndex   int
@@ -85,6 +95,7 @@ func SSHTarget(targetKey string) {
 			fmt.Println()
 
 			if response = cbcli_utils.GetUserInputFromList(
+				"select-instance",
 				"Enter # of instance to SSH to or (q)uit: ",
 				"", options, false); response == "q" {
 				return
@@ -106,16 +117,18 @@ func SSHTarget(targetKey string) {
 			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
 		if state == cloud.StateRunning {
-			if client, err = utils.SSHDialWithKey(
-				managedInstance.SSHAddress(),
-				managedInstance.SSHUser(),
-				managedInstance.SSHKey(),
-			); err != nil {
+			if sshFlags.resetHostKey {
+				if err = resetHostKey(tgt, managedInstance); err != nil {
+					cbcli_utils.ShowErrorAndExit(err.Error())
+				}
+				cbcli_utils.ShowNoticeMessage("The recorded host key of instance \"%s\" has been reset.", managedInstance.Name())
+			}
+			if client, err = dialManagedInstance(tgt, managedInstance); err != nil {
 				cbcli_utils.ShowErrorAndExit(err.Error())
 			}
 			defer client.Close()
 
-			if err = StartTerminal(client, managedInstance.RootPassword()); err != nil {
+			if err = StartTerminal(client.Client, managedInstance.RootPassword()); err != nil {
 				cbcli_utils.ShowErrorAndExit(err.Error())
 			}
 		} else {
@@ -133,7 +146,157 @@ func SSHTarget(targetKey string) {
 	)
 }
 
-func StartTerminal(client *utils.SSHClient, rootPassword string) error {
+// SSH connection to a managed instance. instances
+// without a public address are reached by jumping
+// through the bastion instance of the target's
+// space.
+type instanceConnection struct {
+	*ssh.Client
+
+	jump *ssh.Client
+}
+
+func (c *instanceConnection) Close() error {
+	err := c.Client.Close()
+	if c.jump != nil {
+		c.jump.Close()
+	}
+	return err
+}
+
+// creates an SSH connection to the given managed instance of
+// the target. if the instance does not have a public address
+// the connection is made through the bastion instance using
+// the bastion's SSH key for the first hop and the instance's
+// SSH key for the second.
+func dialManagedInstance(tgt *target.Target, managedInstance *target.ManagedInstance) (*instanceConnection, error) {
+
+	var (
+		err error
+
+		spaceTgt *target.Target
+		bastion  *target.ManagedInstance
+
+		config, bastionConfig *ssh.ClientConfig
+
+		jump   *ssh.Client
+		client *ssh.Client
+		conn   net.Conn
+
+		clientConn ssh.Conn
+		chans      <-chan ssh.NewChannel
+		reqs       <-chan *ssh.Request
+	)
+
+	if config, err = sshClientConfig(tgt, managedInstance); err != nil {
+		return nil, err
+	}
+	if len(managedInstance.PublicIP()) > 0 {
+		if client, err = ssh.Dial("tcp", managedInstance.SSHAddress(), config); err != nil {
+			return nil, err
+		}
+		return &instanceConnection{Client: client}, nil
+	}
+
+	if spaceTgt, bastion, err = bastionInstance(tgt); err != nil {
+		return nil, fmt.Errorf(
+			"instance \"%s\" does not have a public address and cannot be reached via a bastion instance: %s",
+			managedInstance.Name(), err.Error(),
+		)
+	}
+	logger.TraceMessage(
+		"Connecting to instance \"%s\" at %s via bastion instance \"%s\".",
+		managedInstance.Name(), managedInstance.SSHAddress(), bastion.Name(),
+	)
+	if bastionConfig, err = sshClientConfig(spaceTgt, bastion); err != nil {
+		return nil, err
+	}
+	if jump, err = ssh.Dial("tcp", bastion.SSHAddress(), bastionConfig); err != nil {
+		return nil, fmt.Errorf("unable to connect to bastion instance \"%s\": %s", bastion.Name(), err.Error())
+	}
+	if conn, err = jump.Dial("tcp", managedInstance.SSHAddress()); err != nil {
+		jump.Close()
+		return nil, fmt.Errorf(
+			"unable to reach instance \"%s\" from bastion instance \"%s\": %s",
+			managedInstance.Name(), bastion.Name(), err.Error(),
+		)
+	}
+	if clientConn, chans, reqs, err = ssh.NewClientConn(conn, managedInstance.SSHAddress(), config); err != nil {
+		conn.Close()
+		jump.Close()
+		return nil, err
+	}
+	return &instanceConnection{
+		Client: ssh.NewClient(clientConn, chans, reqs),
+		jump:   jump,
+	}, nil
+}
+
+// returns the configuration for an SSH connection to
+// the managed instance which verifies the instance's
+// host key against the key recorded for the target
+func sshClientConfig(tgt *target.Target, managedInstance *target.ManagedInstance) (*ssh.ClientConfig, error) {
+
+	signer, err := ssh.ParsePrivateKey([]byte(managedInstance.SSHKey()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH key for instance \"%s\": %s", managedInstance.Name(), err.Error())
+	}
+	return &ssh.ClientConfig{
+		User:            managedInstance.SSHUser(),
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback(tgt, managedInstance),
+		Timeout:         30 * time.Second,
+	}, nil
+}
+
+// returns the bastion instance of a space target or of
+// the space an application target is attached to along
+// with the space target the bastion belongs to. the
+// bastion is the space's instance with a public address.
+func bastionInstance(tgt *target.Target) (*target.Target, *target.ManagedInstance, error) {
+
+	spaceTgt := tgt
+	if !tgt.Recipe.IsBastion() {
+		dependencies := tgt.Dependencies()
+		if len(dependencies) == 0 {
+			return nil, nil, fmt.Errorf("target \"%s\" is not attached to a space", tgt.DeploymentName())
+		}
+		spaceTgt = dependencies[0]
+	}
+	managedInstances := spaceTgt.ManagedInstances()
+	if len(managedInstances) == 0 {
+		return nil, nil, fmt.Errorf("space target \"%s\" has not been deployed", spaceTgt.DeploymentName())
+	}
+
+	public := []*target.ManagedInstance{}
+	for _, managedInstance := range managedInstances {
+		if len(managedInstance.PublicIP()) > 0 {
+			public = append(public, managedInstance)
+		}
+	}
+	switch len(public) {
+	case 0:
+		return nil, nil, fmt.Errorf(
+			"space target \"%s\" does not have an instance with a public address to use as its bastion",
+			spaceTgt.DeploymentName(),
+		)
+	case 1:
+		return spaceTgt, public[0], nil
+	}
+	// if more than one instance has a public
+	// address the bastion must be named as such
+	for _, managedInstance := range public {
+		if managedInstance.Name() == "bastion" {
+			return spaceTgt, managedInstance, nil
+		}
+	}
+	return nil, nil, fmt.Errorf(
+		"space target \"%s\" has more than one instance with a public address so its bastion instance cannot be determined",
+		spaceTgt.DeploymentName(),
+	)
+}
+
+func StartTerminal(client *ssh.Client, rootPassword string) error {
 
 	var (
 		err error
@@ -142,7 +305,7 @@ func StartTerminal(client *utils.SSHClient, rootPassword string) error {
 		origTermState         *term.State
 		termWidth, termHeight int
 
-		sshTermConfig *utils.SSHTerminalConfig
+		sshTermConfig *terminalConfig
 
 		expectStream *streams.ExpectStream
 		stdinSender  io.ReadCloser
@@ -165,11 +328,11 @@ func StartTerminal(client *utils.SSHClient, rootPassword string) error {
 		if termWidth, termHeight, err = term.GetSize(osStdinFd); err != nil {
 			return err
 		}
-		sshTermConfig = &utils.SSHTerminalConfig{
-			Term:   "xterm-256color",
-			Height: termHeight,
-			Weight: termWidth,
-			Modes: ssh.TerminalModes{
+		sshTermConfig = &terminalConfig{
+			term:   "xterm-256color",
+			height: termHeight,
+			width:  termWidth,
+			modes: ssh.TerminalModes{
 				ssh.ECHO:          1,     // enable echoing
 				ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
 				ssh.TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
@@ -177,11 +340,11 @@ func StartTerminal(client *utils.SSHClient, rootPassword string) error {
 		}
 
 	} else {
-		sshTermConfig = &utils.SSHTerminalConfig{
-			Term:   "xterm",
-			Height: 40,
-			Weight: 80,
-			Modes: ssh.TerminalModes{
+		sshTermConfig = &terminalConfig{
+			term:   "xterm",
+			height: 40,
+			width:  80,
+			modes: ssh.TerminalModes{
 				ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
 				ssh.TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
 			},
@@ -215,20 +378,14 @@ func StartTerminal(client *utils.SSHClient, rootPassword string) error {
 		}
 		expectStream.StartAsShell()
 
-		if err := client.
-			Terminal(sshTermConfig).
-			SetStdio(stdinSender, stdoutSender, stdoutSender).
-			Start(); err != nil {
+		if err := startShell(client, sshTermConfig, stdinSender, stdoutSender, stdoutSender); err != nil {
 
 			// ignore and exit error
 			return nil
 		}
 
 	} else {
-		if err := client.
-			Terminal(sshTermConfig).
-			SetStdio(os.Stdin, os.Stdout, os.Stderr).
-			Start(); err != nil {
+		if err := startShell(client, sshTermConfig, os.Stdin, os.Stdout, os.Stderr); err != nil {
 			return err
 		}
 	}
@@ -236,6 +393,45 @@ func StartTerminal(client *utils.SSHClient, rootPassword string) error {
 	return nil
 }
 
+type terminalConfig struct {
+	term          string
+	height, width int
+	modes         ssh.TerminalModes
+}
+
+// starts an interactive shell on a pseudo terminal
+// and waits for the shell to exit
+func startShell(
+	client *ssh.Client,
+	config *terminalConfig,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) error {
+
+	var (
+		err error
+
+		session *ssh.Session
+	)
+
+	if session, err = client.NewSession(); err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if err = session.RequestPty(config.term, config.height, config.width, config.modes); err != nil {
+		return err
+	}
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err = session.Shell(); err != nil {
+		return err
+	}
+	return session.Wait()
+}
+
 func init() {
 	flags := sshCommand.Flags()
 	flags.SortFlags = false
@@ -243,4 +439,6 @@ func init() {
 
 	flags.BoolVarP(&sshFlags.sudo, "sudo", "u", false, 
 		"sudo to root shell after establishing the SSH session")
+	flags.BoolVar(&sshFlags.resetHostKey, "reset-host-key", false,
+		"discard the recorded host key of the instance before\nconnecting (use when the instance has been rebuilt)")
 }
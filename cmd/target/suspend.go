@@ -19,7 +19,7 @@ import (
 var suspendFlags = struct {
 	commonFlags
 
-	instance string
+	instances []string
 }{}
 
 var suspendCommand = &cobra.Command{
@@ -28,52 +28,36 @@ var suspendCommand = &cobra.Command{
 	Short: "Suspends a running target.",
 	Long: `
 This sub-command suspends all instances deployed to a target. To
-suspend a specific instance provide the instance name via the 
-'-i|--instance' option.
+suspend specific instances provide the instance names via the 
+'-i|--instance' option. The option can be repeated or given a comma
+separated list of names. Instances are stopped in the reverse order
+they were deployed in.
 `,
 
 	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
 
 	Run: func(cmd *cobra.Command, args []string) {
-		SuspendTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(suspendFlags.commonFlags)))
+		SuspendTarget(
+			getTargetKeyFromArgs(args[0], args[1], args[2], &(suspendFlags.commonFlags)),
+			suspendFlags.instances,
+		)
 	},
 	Args: cobra.ExactArgs(3),
 }
 
-func SuspendTarget(targetKey string) {
+func SuspendTarget(targetKey string, instanceNames []string) {
 
 	var (
 		err error
 
-		tgt *target.Target		
-		s   *spinner.Spinner
+		tgt *target.Target
 	)
 
 	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err == nil && tgt != nil {
 
-		if tgt.Status() == target.Running {
-			fmt.Println()
-			if err = tgt.Suspend(
-				func(name string, instance *target.ManagedInstance) {
-					state, _ := instance.State()
-					if state == cloud.StateRunning {						
-						s = spinner.New(
-							spinner.CharSets[cbcli_config.SpinnerNetworkType], 
-							100*time.Millisecond,
-							spinner.WithSuffix(fmt.Sprintf(" Stopping instance \"%s\".", name)),
-							spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" stopped.\n\n", name)),
-							spinner.WithHiddenCursor(true),
-						)
-						s.Start()						
-					} else {
-						s.Stop()
-					}
-				},
-			); err != nil {
-				cbcli_utils.ShowErrorAndExit(err.Error())
-			}
-		} else {
-			cbcli_utils.ShowErrorAndExit("target needs to be 'running' to be suspended")
+		fmt.Println()
+		if err = SuspendTargetInstances(tgt, instanceNames, "suspended via 'cb target suspend'"); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
 		}
 		return
 	}
@@ -86,10 +70,65 @@ func SuspendTarget(targetKey string) {
 	)
 }
 
+// suspends the target's instances with the given names or
+// all of the target's instances if no names are given. the
+// reason is recorded when the entire target is suspended.
+func SuspendTargetInstances(tgt *target.Target, instanceNames []string, reason string) error {
+
+	var (
+		err error
+
+		s *spinner.Spinner
+
+		instances []*target.ManagedInstance
+	)
+
+	if len(instanceNames) > 0 || isPartiallySuspended(tgt) {
+		if tgt.Status() == target.Undeployed {
+			return fmt.Errorf("target needs to be deployed to suspend its instances")
+		}
+		if instances, err = selectManagedInstances(tgt, instanceNames); err != nil {
+			return err
+		}
+		if err = suspendInstances(instances); err != nil {
+			return err
+		}
+		recordSuspendedInstances(tgt, instances, true)
+
+	} else if tgt.Status() != target.Running {
+		return fmt.Errorf("target needs to be 'running' to be suspended")
+
+	} else if err = tgt.Suspend(
+		func(name string, instance *target.ManagedInstance) {
+			state, _ := instance.State()
+			if state == cloud.StateRunning {						
+				s = spinner.New(
+					spinner.CharSets[cbcli_config.SpinnerNetworkType], 
+					100*time.Millisecond,
+					spinner.WithSuffix(fmt.Sprintf(" Stopping instance \"%s\".", name)),
+					spinner.WithFinalMSG(fmt.Sprintf("Instance \"%s\" stopped.\n\n", name)),
+					spinner.WithHiddenCursor(true),
+				)
+				s.Start()						
+			} else {
+				s.Stop()
+			}
+		},
+	); err != nil {
+		return err
+	}
+
+	if len(instanceNames) == 0 {
+		recordSuspendedInstances(tgt, tgt.ManagedInstances(), true)
+		recordSuspension(tgt, reason)
+	}
+	return nil
+}
+
 func init() {
 	flags := suspendCommand.Flags()
 	flags.SortFlags = false
 	bindCommonFlags(flags, &(suspendFlags.commonFlags))
 
-	flags.StringVarP(&suspendFlags.instance, "instance", "i", "", "name of the instance to suspend")
+	flags.StringSliceVarP(&suspendFlags.instances, "instance", "i", []string{}, "name of an instance to suspend")
 }
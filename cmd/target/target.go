@@ -24,15 +24,25 @@ amd manage these target spaces.
 
 func init() {
 	TargetCommands.AddCommand(createCommand)
+	TargetCommands.AddCommand(cloneCommand)
+	TargetCommands.AddCommand(adoptCommand)
 	TargetCommands.AddCommand(listCommand)
 	TargetCommands.AddCommand(showCommand)
 	TargetCommands.AddCommand(configureCommand)
+	TargetCommands.AddCommand(renameCommand)
 	TargetCommands.AddCommand(launchCommand)
+	TargetCommands.AddCommand(applyCommand)
+	TargetCommands.AddCommand(costCommand)
+	TargetCommands.AddCommand(driftCommand)
 	TargetCommands.AddCommand(deleteCommand)
 	TargetCommands.AddCommand(suspendCommand)
 	TargetCommands.AddCommand(resumeCommand)
+	TargetCommands.AddCommand(scheduleCommand)
 	TargetCommands.AddCommand(connectCommand)
 	TargetCommands.AddCommand(sshCommand)
+	TargetCommands.AddCommand(execCommand)
+	TargetCommands.AddCommand(cpCommand)
+	TargetCommands.AddCommand(tunnelCommand)
 }
 
 type commonFlags struct {
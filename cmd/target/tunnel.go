@@ -0,0 +1,496 @@
+package target
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/eiannone/keyboard"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/appbricks/cloud-builder/auth"
+	"github.com/appbricks/cloud-builder/target"
+	"github.com/mevansam/gocloud/cloud"
+	"github.com/mevansam/goutils/logger"
+	"github.com/mevansam/goutils/run"
+	"github.com/mevansam/goutils/utils"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var tunnelFlags = struct {
+	commonFlags
+
+	local   []string
+	remote  []string
+	dynamic []string
+}{}
+
+var tunnelCommand = &cobra.Command{
+	Use: "tunnel [recipe] [cloud] [deployment name]",
+
+	Short: "Forward ports through a target's bastion instance.",
+	Long: `
+Forwards ports over an SSH connection to the bastion instance of a
+space target or of the space an application target is attached to.
+Unlike 'cb target connect' this does not create a VPN connection so
+it does not require admin privileges on this device. The following
+forwards can be given and each option can be repeated.
+
+  -L [bind address:]port:host:host port
+     listen on the local port and forward connections to the host
+     and port reachable from the bastion instance
+  -R [bind address:]port:host:host port
+     listen on the port of the bastion instance and forward
+     connections to the host and port reachable from this device
+  -D [bind address:]port
+     listen on the local port as a SOCKS5 proxy that connects to
+     any host reachable from the bastion instance
+
+For example:
+
+  cb target tunnel sandbox aws MyVPN -r us-east-1 -L 8080:10.0.1.10:80 -D 1080
+`,
+
+	PreRun: cbcli_auth.AssertAuthorized(auth.NewRoleMask(auth.Admin), nil),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		TunnelTarget(getTargetKeyFromArgs(args[0], args[1], args[2], &(tunnelFlags.commonFlags)))
+	},
+	Args: cobra.ExactArgs(3),
+}
+
+// a port forward of a tunnel
+type portForward struct {
+	kind string // "local", "remote" or "socks"
+
+	listenAddr string
+	targetAddr string
+
+	listener net.Listener
+}
+
+func (f *portForward) String() string {
+	switch f.kind {
+	case "local":
+		return fmt.Sprintf("local %s -> %s", f.listenAddr, f.targetAddr)
+	case "remote":
+		return fmt.Sprintf("remote %s -> %s", f.listenAddr, f.targetAddr)
+	default:
+		return fmt.Sprintf("socks5 %s", f.listenAddr)
+	}
+}
+
+// traffic counters of a tunnel
+type tunnelStats struct {
+	active   int64
+	sent     int64
+	received int64
+}
+
+func TunnelTarget(targetKey string) {
+
+	var (
+		err error
+
+		tgt, spaceTgt *target.Target
+
+		bastion  *target.ManagedInstance
+		state    cloud.InstanceState
+		client   *instanceConnection
+		forwards []*portForward
+	)
+
+	if tgt, err = cbcli_config.Config.TargetContext().GetTarget(targetKey); err != nil || tgt == nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf(
+				"Target \"%s\" does not exist. Run 'cb target list' to list the currently configured targets",
+				targetKey,
+			),
+		)
+	}
+	if forwards, err = parsePortForwards(tunnelFlags.local, tunnelFlags.remote, tunnelFlags.dynamic); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if len(forwards) == 0 {
+		cbcli_utils.ShowErrorAndExit("Provide at least one port forward via the '-L', '-R' or '-D' options.")
+	}
+
+	if spaceTgt, bastion, err = bastionInstance(tgt); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if state, err = bastion.State(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	if state != cloud.StateRunning {
+		cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Bastion instance \"%s\" is not running.", bastion.Name()))
+	}
+	if client, err = dialManagedInstance(spaceTgt, bastion); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	defer client.Close()
+	sshClient := client.Client
+
+	stats := &tunnelStats{}
+	for _, f := range forwards {
+		if f.kind == "remote" {
+			f.listener, err = sshClient.Listen("tcp", f.listenAddr)
+		} else {
+			f.listener, err = net.Listen("tcp", f.listenAddr)
+		}
+		if err != nil {
+			cbcli_utils.ShowErrorAndExit(fmt.Sprintf("Unable to listen on %s: %s", f, err.Error()))
+		}
+		defer f.listener.Close()
+		go f.serve(sshClient, stats)
+	}
+
+	fmt.Println()
+	cbcli_utils.ShowInfoMessage("Forwarding through bastion instance \"%s\" of target \"%s\".", bastion.Name(), tgt.DeploymentName())
+	for _, f := range forwards {
+		fmt.Printf("  - %s\n", f)
+	}
+	fmt.Println()
+
+	// trap keyboard exit/termination event
+	disconnect := make(chan bool, 2)
+
+	if runtime.GOOS == "windows" {
+		// ctrl-c is not trapped correctly in windows
+		// as we also wait on keyboard. this handler
+		// traps the event at the win32 API and handles
+		// the interrupt to the connection.
+		if err = run.HandleInterruptEvent(
+			func() bool {
+				disconnect <- true
+				return true
+			},
+		); err != nil {
+			cbcli_utils.ShowErrorAndExit(err.Error())
+		}
+	}
+
+	if err := keyboard.Open(); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+	defer func() {
+		_ = keyboard.Close()
+	}()
+	go func() {
+		// the key and error are local so they are not
+		// shared with the tunnel's main loop
+		var (
+			err error
+			key keyboard.Key
+		)
+
+		if runtime.GOOS == "windows" {
+			// don't handle ctrl-c for windows as that
+			// is handled via the interrupt event handler
+			for key != keyboard.KeyCtrlX {
+				if _, key, err = keyboard.GetKey(); err != nil {
+					if err.Error() != "operation canceled" {
+						logger.ErrorMessage(
+							"TunnelTarget: Unable to pause for key input. Received error: %s",
+							err.Error(),
+						)
+					}
+					break
+				}
+			}
+		} else {
+			for key != keyboard.KeyCtrlX && key != keyboard.KeyCtrlC {
+				if _, key, err = keyboard.GetKey(); err != nil {
+					logger.ErrorMessage(
+						"TunnelTarget: Unable to pause for key input. Received error: %s",
+						err.Error(),
+					)
+					break
+				}
+			}
+		}
+		disconnect <- true
+	}()
+
+	// end the session if the
+	// SSH connection is lost
+	connectionLost := make(chan error, 1)
+	go func() {
+		connectionLost <- sshClient.Wait()
+	}()
+
+	s := spinner.New(
+		spinner.CharSets[cbcli_config.SpinnerNetworkType],
+		100*time.Millisecond,
+		spinner.WithSuffix(" Press CTRL-x or CTRL-c to disconnect."),
+		spinner.WithFinalMSG("Tunnel has been disconnected.\n"),
+		spinner.WithHiddenCursor(true),
+	)
+
+	setStatus := func() {
+		s.Prefix = fmt.Sprintf(
+			"%d connections, recd %s, sent %s ",
+			atomic.LoadInt64(&stats.active),
+			utils.ByteCountIEC(atomic.LoadInt64(&stats.received)),
+			utils.ByteCountIEC(atomic.LoadInt64(&stats.sent)),
+		)
+	}
+	setStatus()
+	s.Start()
+
+	for {
+		select {
+		case <-disconnect:
+			s.Stop()
+			fmt.Println()
+			return
+		case err = <-connectionLost:
+			s.Stop()
+			fmt.Println()
+			msg := "The SSH connection to the bastion instance was lost."
+			if err != nil {
+				msg = fmt.Sprintf("The SSH connection to the bastion instance was lost: %s", err.Error())
+			}
+			cbcli_utils.ShowErrorMessage(msg)
+			cbcli_utils.ExitCode = 1
+			return
+		case <-time.After(time.Millisecond * 500):
+		}
+		setStatus()
+	}
+}
+
+// accepts connections on the forward's listener
+// and forwards them until the listener is closed
+func (f *portForward) serve(sshClient *ssh.Client, stats *tunnelStats) {
+
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			logger.TraceMessage("portForward.serve(): Listener for %s closed: %s", f, err.Error())
+			return
+		}
+
+		go func() {
+			var (
+				err error
+				dst net.Conn
+			)
+
+			switch f.kind {
+			case "local":
+				dst, err = sshClient.Dial("tcp", f.targetAddr)
+			case "remote":
+				dst, err = net.Dial("tcp", f.targetAddr)
+			default:
+				dst, err = socksConnect(conn, sshClient)
+			}
+			if err != nil {
+				logger.DebugMessage("portForward.serve(): Unable to forward connection for %s: %s", f, err.Error())
+				conn.Close()
+				return
+			}
+
+			atomic.AddInt64(&stats.active, 1)
+			defer atomic.AddInt64(&stats.active, -1)
+
+			// traffic is counted from the
+			// perspective of this device
+			sent, received := &stats.sent, &stats.received
+			if f.kind == "remote" {
+				sent, received = received, sent
+			}
+			pipeConns(conn, dst, sent, received)
+		}()
+	}
+}
+
+// a writer that adds the number of bytes
+// written through it to a shared counter
+type countingWriter struct {
+	out   io.Writer
+	count *int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	atomic.AddInt64(w.count, int64(n))
+	return n, err
+}
+
+// copies data between the two connections until either
+// is closed counting the bytes copied in each direction
+// as they are copied so long lived connections are
+// reflected in the counters while they are open
+func pipeConns(conn, dst net.Conn, sent, received *int64) {
+
+	var wg sync.WaitGroup
+	pipe := func(to, from net.Conn, count *int64) {
+		defer wg.Done()
+		_, _ = io.Copy(&countingWriter{out: to, count: count}, from)
+		// unblock the copy in the other direction
+		to.Close()
+		from.Close()
+	}
+	wg.Add(2)
+	go pipe(dst, conn, sent)
+	go pipe(conn, dst, received)
+	wg.Wait()
+}
+
+// parses the local, remote and dynamic port forward options
+func parsePortForwards(local, remote, dynamic []string) ([]*portForward, error) {
+
+	forwards := []*portForward{}
+
+	parse := func(kind, spec string) error {
+		parts := strings.Split(spec, ":")
+		f := &portForward{kind: kind}
+
+		switch {
+		case kind == "socks" && len(parts) == 1:
+			f.listenAddr = net.JoinHostPort("localhost", parts[0])
+		case kind == "socks" && len(parts) == 2:
+			f.listenAddr = net.JoinHostPort(parts[0], parts[1])
+		case kind != "socks" && len(parts) == 3:
+			f.listenAddr = net.JoinHostPort("localhost", parts[0])
+			f.targetAddr = net.JoinHostPort(parts[1], parts[2])
+		case kind != "socks" && len(parts) == 4:
+			f.listenAddr = net.JoinHostPort(parts[0], parts[1])
+			f.targetAddr = net.JoinHostPort(parts[2], parts[3])
+		default:
+			return fmt.Errorf("invalid port forward '%s'", spec)
+		}
+		for _, addr := range []string{f.listenAddr, f.targetAddr} {
+			if len(addr) == 0 {
+				continue
+			}
+			_, port, _ := net.SplitHostPort(addr)
+			if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+				return fmt.Errorf("invalid port '%s' in port forward '%s'", port, spec)
+			}
+		}
+		forwards = append(forwards, f)
+		return nil
+	}
+
+	for _, spec := range local {
+		if err := parse("local", spec); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range remote {
+		if err := parse("remote", spec); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range dynamic {
+		if err := parse("socks", spec); err != nil {
+			return nil, err
+		}
+	}
+	return forwards, nil
+}
+
+// handles the SOCKS5 handshake on the connection and
+// returns a connection to the requested destination
+// via the SSH connection. only the CONNECT command
+// without authentication is supported.
+func socksConnect(conn net.Conn, sshClient *ssh.Client) (net.Conn, error) {
+
+	var (
+		err error
+
+		host string
+	)
+
+	buf := make([]byte, 256)
+
+	// greeting: version, number of methods, methods
+	if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+		return nil, err
+	}
+	if buf[0] != 5 {
+		return nil, fmt.Errorf("unsupported SOCKS version %d", buf[0])
+	}
+	if _, err = io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return nil, err
+	}
+	// no authentication required
+	if _, err = conn.Write([]byte{5, 0}); err != nil {
+		return nil, err
+	}
+
+	// request: version, command, reserved, address type
+	if _, err = io.ReadFull(conn, buf[:4]); err != nil {
+		return nil, err
+	}
+	if buf[1] != 1 {
+		conn.Write([]byte{5, 7, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, fmt.Errorf("unsupported SOCKS command %d", buf[1])
+	}
+	switch buf[3] {
+	case 1:
+		if _, err = io.ReadFull(conn, buf[:4]); err != nil {
+			return nil, err
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err = io.ReadFull(conn, buf[:1]); err != nil {
+			return nil, err
+		}
+		l := int(buf[0])
+		if _, err = io.ReadFull(conn, buf[:l]); err != nil {
+			return nil, err
+		}
+		host = string(buf[:l])
+	case 4:
+		if _, err = io.ReadFull(conn, buf[:16]); err != nil {
+			return nil, err
+		}
+		host = net.IP(buf[:16]).String()
+	default:
+		conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, fmt.Errorf("unsupported SOCKS address type %d", buf[3])
+	}
+	if _, err = io.ReadFull(conn, buf[:2]); err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+
+	dst, err := sshClient.Dial("tcp", addr)
+	if err != nil {
+		// host unreachable
+		conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+	if _, err = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		dst.Close()
+		return nil, err
+	}
+	return dst, nil
+}
+
+func init() {
+	flags := tunnelCommand.Flags()
+	flags.SortFlags = false
+	bindCommonFlags(flags, &(tunnelFlags.commonFlags))
+
+	flags.StringArrayVarP(&tunnelFlags.local, "local", "L", []string{},
+		"forward a local port to a host reachable from the bastion\n(format [bind address:]port:host:host port)")
+	flags.StringArrayVarP(&tunnelFlags.remote, "remote", "R", []string{},
+		"forward a port of the bastion to a host reachable from this\ndevice (format [bind address:]port:host:host port)")
+	flags.StringArrayVarP(&tunnelFlags.dynamic, "dynamic", "D", []string{},
+		"listen on a local port as a SOCKS5 proxy via the bastion\n(format [bind address:]port)")
+}
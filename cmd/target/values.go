@@ -0,0 +1,161 @@
+package target
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gookit/color"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mevansam/goforms/forms"
+
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+// target configuration values that are read from a
+// YAML or JSON file instead of being entered via the
+// target's input forms. the format is documented in
+// doc/target-values.md.
+type targetValues struct {
+	// space target key an application target
+	// should be deployed to
+	Space string `yaml:"space,omitempty"`
+
+	Provider map[string]interface{} `yaml:"provider,omitempty"`
+	Recipe   map[string]interface{} `yaml:"recipe,omitempty"`
+	Backend  map[string]interface{} `yaml:"backend,omitempty"`
+
+	// file the values were loaded from and errors
+	// found when applying the values to the forms
+	file   string
+	errors []string
+}
+
+func loadTargetValues(valuesFile string) *targetValues {
+
+	var (
+		err error
+
+		data []byte
+	)
+
+	if len(valuesFile) == 0 {
+		return nil
+	}
+	if data, err = os.ReadFile(valuesFile); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to read values file '%s': %s", valuesFile, err.Error()),
+		)
+	}
+	// JSON is a subset of YAML so a
+	// JSON values file is also parsed
+	values := &targetValues{file: valuesFile}
+	if err = yaml.Unmarshal(data, values); err != nil {
+		cbcli_utils.ShowErrorAndExit(
+			fmt.Sprintf("Unable to parse values file '%s': %s", valuesFile, err.Error()),
+		)
+	}
+	return values
+}
+
+// sets the given section's values on the form. all
+// values that are unknown or fail the input field's
+// validation as well as any enabled inputs that do
+// not have a value are recorded as errors.
+func (v *targetValues) apply(
+	section string,
+	inputForm forms.InputForm,
+	values map[string]interface{},
+	tags ...string,
+) {
+
+	var (
+		err error
+
+		field *forms.InputField
+	)
+
+	enabled := make(map[string]bool)
+	for _, f := range inputForm.EnabledInputs(false, tags...) {
+		enabled[f.Name()] = true
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if field, err = inputForm.GetInputField(name); err != nil {
+			v.errors = append(v.errors,
+				fmt.Sprintf("%s.%s: unknown field", section, name))
+			continue
+		}
+		if !enabled[name] {
+			v.errors = append(v.errors,
+				fmt.Sprintf("%s.%s: field cannot be configured for this target", section, name))
+			continue
+		}
+		value := valueString(values[name])
+		if err = field.SetValue(value); err != nil {
+			v.errors = append(v.errors,
+				fmt.Sprintf("%s.%s: %s", section, name, err.Error()))
+		}
+	}
+
+	for _, f := range inputForm.EnabledInputs(true, tags...) {
+		if f.Value() == nil {
+			v.errors = append(v.errors,
+				fmt.Sprintf("%s.%s: value is required", section, f.Name()))
+		}
+	}
+}
+
+// records an error that is not
+// specific to a particular field
+func (v *targetValues) addError(format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf(format, args...))
+}
+
+// shows all errors found when applying the values
+// and exits if the values could not be applied
+func (v *targetValues) assertValid() {
+
+	if len(v.errors) == 0 {
+		return
+	}
+	cbcli_utils.ShowErrorMessage(
+		fmt.Sprintf(
+			"Values file '%s' has missing or invalid fields.",
+			v.file,
+		),
+	)
+	for _, e := range v.errors {
+		fmt.Println(color.Red.Render("- " + e))
+	}
+	fmt.Println()
+	os.Exit(1)
+}
+
+func valueString(value interface{}) *string {
+
+	var s string
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case bool:
+		if v {
+			s = "true"
+		} else {
+			s = "false"
+		}
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	return &s
+}
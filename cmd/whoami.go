@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/appbricks/cloud-builder/auth"
+
+	cbcli_auth "github.com/appbricks/cloud-builder-cli/auth"
+	cbcli_config "github.com/appbricks/cloud-builder-cli/config"
+	cbcli_utils "github.com/appbricks/cloud-builder-cli/utils"
+)
+
+var whoamiCommand = &cobra.Command{
+	Use: "whoami",
+
+	Short: "Show the logged in user.",
+	Long: `
+Shows the user currently logged in, the user's role on this device,
+when the user's session expires and the timestamp of the user's
+configuration. Exits with a non-zero code if no user is logged in.
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		WhoAmI()
+	},
+	Args: cobra.ExactArgs(0),
+}
+
+// structured output schema of the
+// 'whoami' command's result
+type whoamiOutput struct {
+	User            string `json:"user" yaml:"user"`
+	UserID          string `json:"userID" yaml:"userID"`
+	Device          string `json:"device" yaml:"device"`
+	Role            string `json:"role" yaml:"role"`
+	Authorized      bool   `json:"authorized" yaml:"authorized"`
+	TokenExpiry     string `json:"tokenExpiry,omitempty" yaml:"tokenExpiry,omitempty"`
+	ConfigTimestamp string `json:"configTimestamp,omitempty" yaml:"configTimestamp,omitempty"`
+}
+
+func WhoAmI() {
+
+	var (
+		err error
+
+		awsAuth *cbcli_auth.AWSCognitoJWT
+	)
+
+	config := cbcli_config.Config
+	deviceContext := config.DeviceContext()
+
+	if !config.AuthContext().IsLoggedIn() {
+		fmt.Println()
+		cbcli_utils.ShowNoticeMessage("You are not logged in. Run 'cb login' to login.")
+		fmt.Println()
+		os.Exit(1)
+	}
+	if awsAuth, err = cbcli_auth.NewAWSCognitoJWT(config); err != nil {
+		cbcli_utils.ShowErrorAndExit(err.Error())
+	}
+
+	deviceName, _ := deviceContext.GetDeviceName()
+	output := whoamiOutput{
+		User:            awsAuth.Username(),
+		UserID:          awsAuth.UserID(),
+		Device:          deviceName,
+		Role:            auth.RoleFromContext(deviceContext, nil).String(),
+		Authorized:      deviceContext.IsAuthorizedUser(awsAuth.Username()),
+		ConfigTimestamp: formatTimestamp(config.GetConfigAsOf()),
+	}
+	if token := config.AuthContext().GetToken(); token != nil && !token.Expiry.IsZero() {
+		output.TokenExpiry = token.Expiry.Local().Format(time.RFC3339)
+	}
+
+	if !cbcli_utils.IsTableOutput() {
+		cbcli_utils.RenderOutput(output)
+		return
+	}
+
+	authorized := "yes"
+	if !output.Authorized {
+		authorized = "no (pending)"
+	}
+	fmt.Printf("\nUser:              %s\n", output.User)
+	fmt.Printf("User ID:           %s\n", output.UserID)
+	fmt.Printf("Device:            %s\n", output.Device)
+	fmt.Printf("Role:              %s\n", output.Role)
+	fmt.Printf("Authorized:        %s\n", authorized)
+	fmt.Printf("Token Expiry:      %s\n", valueOrNone(output.TokenExpiry))
+	fmt.Printf("Config Timestamp:  %s\n\n", valueOrNone(output.ConfigTimestamp))
+}
+
+// formats the config timestamp which may be
+// in seconds or milliseconds since the epoch
+func formatTimestamp(ts int64) string {
+	if ts == 0 {
+		return ""
+	}
+	if ts > 1e12 {
+		return time.UnixMilli(ts).Local().Format(time.RFC3339)
+	}
+	return time.Unix(ts, 0).Local().Format(time.RFC3339)
+}
+
+func valueOrNone(value string) string {
+	if len(value) == 0 {
+		return "n/a"
+	}
+	return value
+}
@@ -11,6 +11,7 @@ const CLIENT_ID = ""
 const CLIENT_SECRET = ""
 const AUTH_URL = ""
 const TOKEN_URL = ""
+const DEVICE_AUTH_URL = ""
 const USER_INFO_URL = ""
 
 const AWS_APPSYNC_REGION = ""
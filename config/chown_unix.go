@@ -0,0 +1,31 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// changes the owner of the given file to the owner of
+// its parent directory if running with root privileges
+func chownToParent(path string) error {
+
+	var (
+		err error
+
+		info os.FileInfo
+	)
+
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	if info, err = os.Stat(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return os.Chown(path, int(stat.Uid), int(stat.Gid))
+	}
+	return nil
+}
@@ -12,6 +12,15 @@ import (
 var (
 	// Global configuration
 	Config config.Config
+	// Path of the configuration file
+	ConfigFile string
+
+	// Passphrase the configuration was unlocked with
+	Passphrase string
+	// Reloads the configuration from file so long
+	// running commands pick up changes made by
+	// other commands
+	ReloadConfig func() error
 
 	// Monitor Service
 	MonitorService *monitors.MonitorService
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// OAuth 2.0 / OIDC endpoints used to authenticate the
+// CLI user. these default to the MyCS endpoints.
+var (
+	OIDCTokenURL      = TOKEN_URL
+	OIDCDeviceAuthURL = DEVICE_AUTH_URL
+	OIDCJWKSURL       = fmt.Sprintf(
+		"https://cognito-idp.%s.amazonaws.com/%s/.well-known/jwks.json",
+		AWS_COGNITO_REGION,
+		AWS_COGNITO_USER_POOL_ID,
+	)
+)
+
+// overrides the OIDC endpoints via the environment in
+// order to test the authentication flows against a local
+// stand-in OIDC server. as the JWKS endpoint provides the
+// keys the JWT signatures are verified with this must only
+// be called for non-prod builds.
+func OverrideOIDCEndpoints() {
+	OIDCTokenURL = getEnvOrDefault("CBS_OIDC_TOKEN_URL", OIDCTokenURL)
+	OIDCDeviceAuthURL = getEnvOrDefault("CBS_OIDC_DEVICE_AUTH_URL", OIDCDeviceAuthURL)
+	OIDCJWKSURL = getEnvOrDefault("CBS_OIDC_JWKS_URL", OIDCJWKSURL)
+}
+
+func getEnvOrDefault(name, defaultValue string) string {
+	if value := os.Getenv(name); len(value) > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ScheduleSuspend = "suspend"
+	ScheduleResume  = "resume"
+)
+
+// the furthest back missed windows are looked for
+const maxScheduleCatchUp = 7 * 24 * time.Hour
+
+// validates the schedule's cron expressions and timezone
+func (s *TargetSchedule) Validate() error {
+
+	var (
+		err error
+	)
+
+	if len(s.Suspend) == 0 && len(s.Resume) == 0 {
+		return fmt.Errorf("a schedule requires a suspend or a resume window")
+	}
+	if _, err = s.location(); err != nil {
+		return err
+	}
+	if _, err = s.parse(s.Suspend); err != nil {
+		return fmt.Errorf("invalid suspend schedule '%s': %s", s.Suspend, err.Error())
+	}
+	if _, err = s.parse(s.Resume); err != nil {
+		return fmt.Errorf("invalid resume schedule '%s': %s", s.Resume, err.Error())
+	}
+	return nil
+}
+
+// returns the time of the next suspend and resume windows
+// after the given time. a zero time is returned for a
+// window that has not been scheduled.
+func (s *TargetSchedule) Next(after time.Time) (time.Time, time.Time, error) {
+
+	var (
+		err error
+
+		suspend, resume cron.Schedule
+		nextSuspend     time.Time
+		nextResume      time.Time
+	)
+
+	if suspend, err = s.parse(s.Suspend); err != nil {
+		return nextSuspend, nextResume, err
+	}
+	if resume, err = s.parse(s.Resume); err != nil {
+		return nextSuspend, nextResume, err
+	}
+	if suspend != nil {
+		nextSuspend = suspend.Next(after)
+	}
+	if resume != nil {
+		nextResume = resume.Next(after)
+	}
+	return nextSuspend, nextResume, nil
+}
+
+// returns the action of the latest window that opened in
+// the interval (since, until]. if more than one window was
+// missed, for example because the machine was asleep, only
+// the latest window needs to be acted on. an empty action
+// is returned if no window opened in the interval.
+func (s *TargetSchedule) Due(since, until time.Time) (string, time.Time, error) {
+
+	var (
+		err error
+
+		suspend, resume cron.Schedule
+		action          string
+		at              time.Time
+	)
+
+	if suspend, err = s.parse(s.Suspend); err != nil {
+		return "", at, err
+	}
+	if resume, err = s.parse(s.Resume); err != nil {
+		return "", at, err
+	}
+	if since.IsZero() || until.Sub(since) > maxScheduleCatchUp {
+		since = until.Add(-maxScheduleCatchUp)
+	}
+
+	if t := lastWindow(suspend, since, until); !t.IsZero() {
+		action, at = ScheduleSuspend, t
+	}
+	if t := lastWindow(resume, since, until); !t.IsZero() && t.After(at) {
+		action, at = ScheduleResume, t
+	}
+	return action, at, nil
+}
+
+func (s *TargetSchedule) location() (*time.Location, error) {
+	if len(s.Timezone) == 0 {
+		return time.Local, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+func (s *TargetSchedule) parse(spec string) (cron.Schedule, error) {
+
+	var (
+		err error
+
+		location *time.Location
+		schedule cron.Schedule
+	)
+
+	if len(spec) == 0 {
+		return nil, nil
+	}
+	if location, err = s.location(); err != nil {
+		return nil, err
+	}
+	if schedule, err = cron.ParseStandard(spec); err != nil {
+		return nil, err
+	}
+	if specSchedule, ok := schedule.(*cron.SpecSchedule); ok && len(s.Timezone) > 0 {
+		specSchedule.Location = location
+	}
+	return schedule, nil
+}
+
+// returns the time of the last window of the
+// given schedule in the interval (since, until]
+func lastWindow(schedule cron.Schedule, since, until time.Time) time.Time {
+
+	var (
+		last time.Time
+	)
+
+	if schedule == nil {
+		return last
+	}
+	for t := schedule.Next(since); !t.IsZero() && !t.After(until); t = schedule.Next(t) {
+		last = t
+	}
+	return last
+}
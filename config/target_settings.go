@@ -0,0 +1,466 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// CLI specific settings of a target such as its suspend
+// and resume schedule. the targets in the target context
+// are defined by the cloud-builder package and cannot hold
+// settings specific to this CLI, so these settings are
+// saved keyed by the target's key to a file in the same
+// directory as the configuration file. the file is
+// encrypted and authenticated with a key derived from the
+// passphrase the configuration is unlocked with. the
+// settings follow the target when it is renamed, deleted,
+// exported or imported but unlike the target context they
+// are not synced to the user's other devices. they record
+// what this device does on behalf of the target, i.e. the
+// windows evaluated by the scheduler running on this
+// device, which would otherwise be acted on by the
+// scheduler on each of the user's devices.
+type TargetSettings struct {
+	Schedule   *TargetSchedule `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	IdlePolicy *IdlePolicy     `yaml:"idlePolicy,omitempty" json:"idlePolicy,omitempty"`
+
+	// reason the target was last suspended
+	Suspended *SuspendRecord `yaml:"suspended,omitempty" json:"suspended,omitempty"`
+	// names of instances that have been suspended
+	// individually while the rest are running
+	SuspendedInstances []string `yaml:"suspendedInstances,omitempty" json:"suspendedInstances,omitempty"`
+
+	// SHA256 fingerprints of the SSH host keys of
+	// the target's instances keyed by instance name
+	HostKeys map[string]string `yaml:"hostKeys,omitempty" json:"hostKeys,omitempty"`
+
+	// resources deployed by the target which are
+	// recorded from the plans its launches apply.
+	// nil if the deployed resources are not known.
+	Deployed *DeployedResources `yaml:"deployed,omitempty" json:"deployed,omitempty"`
+}
+
+func (s *TargetSettings) isEmpty() bool {
+	return s.Schedule == nil && s.IdlePolicy == nil && s.Suspended == nil &&
+		len(s.SuspendedInstances) == 0 && len(s.HostKeys) == 0 && s.Deployed == nil
+}
+
+// resources deployed by a target keyed by their address
+type DeployedResources struct {
+	Resources map[string]*DeployedResource `yaml:"resources,omitempty" json:"resources,omitempty"`
+}
+
+// a deployed resource with the scalar attribute
+// values the launch that last changed it applied
+type DeployedResource struct {
+	Type       string            `yaml:"type" json:"type"`
+	Attributes map[string]string `yaml:"attributes,omitempty" json:"attributes,omitempty"`
+}
+
+// cron style suspend and resume windows of a target
+type TargetSchedule struct {
+	Suspend  string `yaml:"suspend,omitempty" json:"suspend,omitempty"`
+	Resume   string `yaml:"resume,omitempty" json:"resume,omitempty"`
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+
+	// time the scheduler last evaluated the schedule
+	// which is used to catch up on missed windows
+	LastEvaluated time.Time `yaml:"lastEvaluated,omitempty" json:"lastEvaluated,omitempty"`
+}
+
+// suspends a space if there has been no traffic
+// on the space's mesh network for a period of time
+type IdlePolicy struct {
+	SuspendAfter time.Duration `yaml:"suspendAfter" json:"suspendAfter"`
+	WarnBefore   time.Duration `yaml:"warnBefore,omitempty" json:"warnBefore,omitempty"`
+	// traffic in bytes per minute below which
+	// the mesh network is considered idle
+	MinTraffic int64 `yaml:"minTraffic,omitempty" json:"minTraffic,omitempty"`
+}
+
+type SuspendRecord struct {
+	Reason string    `yaml:"reason" json:"reason"`
+	At     time.Time `yaml:"at" json:"at"`
+}
+
+const targetSettingsVersion = 1
+
+// encrypted target settings file
+type encryptedSettings struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// key the settings are encrypted with. the key is
+// derived once per process as it is expensive to
+// derive and the salt is retained across saves.
+var settingsKey = struct {
+	mx sync.Mutex
+
+	passphrase string
+	salt       []byte
+	key        []byte
+}{}
+
+func targetSettingsFile() string {
+	return filepath.Join(filepath.Dir(ConfigFile), "targets.dat")
+}
+
+// returns the settings of the target with the given
+// key. if the target has no settings then empty
+// settings are returned.
+func GetTargetSettings(key string) (*TargetSettings, error) {
+
+	var (
+		err error
+
+		settings map[string]*TargetSettings
+	)
+
+	if settings, err = readTargetSettings(Passphrase); err != nil {
+		return nil, err
+	}
+	if s, exists := settings[key]; exists {
+		return s, nil
+	}
+	return &TargetSettings{}, nil
+}
+
+// returns the settings of all targets keyed by target
+func AllTargetSettings() (map[string]*TargetSettings, error) {
+	return readTargetSettings(Passphrase)
+}
+
+// returns the keys of all targets with settings
+func TargetSettingsKeys() ([]string, error) {
+
+	var (
+		err error
+
+		settings map[string]*TargetSettings
+	)
+
+	if settings, err = readTargetSettings(Passphrase); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// saves the settings of the target with the given key.
+// the settings of other targets are re-read under a lock
+// so that changes made by other commands are retained.
+func SetTargetSettings(key string, settings *TargetSettings) error {
+	return updateTargetSettings(func(all map[string]*TargetSettings) error {
+		if settings.isEmpty() {
+			delete(all, key)
+		} else {
+			all[key] = settings
+		}
+		return nil
+	})
+}
+
+// applies the given update to the settings of the target
+// with the given key while holding the settings lock so
+// that concurrent updates of the same target are not lost
+func UpdateTargetSettings(key string, update func(settings *TargetSettings) error) error {
+	return updateTargetSettings(func(all map[string]*TargetSettings) error {
+		settings, exists := all[key]
+		if !exists {
+			settings = &TargetSettings{}
+		}
+		if err := update(settings); err != nil {
+			return err
+		}
+		if settings.isEmpty() {
+			delete(all, key)
+		} else {
+			all[key] = settings
+		}
+		return nil
+	})
+}
+
+// removes the settings of the target with the given key
+func DeleteTargetSettings(key string) error {
+	return SetTargetSettings(key, &TargetSettings{})
+}
+
+// moves the settings of a target to a new key
+// when the target's key changes
+func RenameTargetSettings(key, newKey string) error {
+	return updateTargetSettings(func(all map[string]*TargetSettings) error {
+		if settings, exists := all[key]; exists {
+			delete(all, key)
+			all[newKey] = settings
+		}
+		return nil
+	})
+}
+
+// merges the given target settings, for example from an
+// imported configuration, with the saved settings
+func ImportTargetSettings(settings map[string]*TargetSettings) error {
+	return updateTargetSettings(func(all map[string]*TargetSettings) error {
+		for key, s := range settings {
+			if s.isEmpty() {
+				delete(all, key)
+			} else {
+				all[key] = s
+			}
+		}
+		return nil
+	})
+}
+
+// re-encrypts the settings when the passphrase the
+// configuration is unlocked with is changed
+func ReencryptTargetSettings(passphrase, newPassphrase string) error {
+
+	var (
+		err error
+
+		unlock   func()
+		settings map[string]*TargetSettings
+	)
+
+	if unlock, err = lockTargetSettings(); err != nil {
+		return err
+	}
+	defer unlock()
+
+	if settings, err = readTargetSettings(passphrase); err != nil {
+		return err
+	}
+	return writeTargetSettings(newPassphrase, settings)
+}
+
+func updateTargetSettings(update func(all map[string]*TargetSettings) error) error {
+
+	var (
+		err error
+
+		unlock   func()
+		settings map[string]*TargetSettings
+	)
+
+	if unlock, err = lockTargetSettings(); err != nil {
+		return err
+	}
+	defer unlock()
+
+	if settings, err = readTargetSettings(Passphrase); err != nil {
+		return err
+	}
+	if err = update(settings); err != nil {
+		return err
+	}
+	return writeTargetSettings(Passphrase, settings)
+}
+
+func readTargetSettings(passphrase string) (map[string]*TargetSettings, error) {
+
+	var (
+		err error
+
+		data []byte
+		key  []byte
+		aead cipher.AEAD
+	)
+
+	settings := make(map[string]*TargetSettings)
+
+	if data, err = os.ReadFile(targetSettingsFile()); err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return nil, err
+	}
+	file := &encryptedSettings{}
+	if err = json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("the target settings file '%s' is not valid", targetSettingsFile())
+	}
+	if file.Version != targetSettingsVersion {
+		return nil, fmt.Errorf("target settings file version %d is not supported", file.Version)
+	}
+	if key, err = targetSettingsKey(passphrase, file.Salt); err != nil {
+		return nil, err
+	}
+	if aead, err = newSettingsAEAD(key); err != nil {
+		return nil, err
+	}
+	if data, err = aead.Open(nil, file.Nonce, file.Data, nil); err != nil {
+		return nil, fmt.Errorf(
+			"unable to decrypt the target settings file '%s'. it has been modified or was not encrypted with the configuration passphrase",
+			targetSettingsFile(),
+		)
+	}
+	if err = yaml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = make(map[string]*TargetSettings)
+	}
+	return settings, nil
+}
+
+func writeTargetSettings(passphrase string, settings map[string]*TargetSettings) error {
+
+	var (
+		err error
+
+		data    []byte
+		key     []byte
+		aead    cipher.AEAD
+		tmpFile *os.File
+	)
+
+	if data, err = yaml.Marshal(settings); err != nil {
+		return err
+	}
+
+	settingsKey.mx.Lock()
+	salt := settingsKey.salt
+	if passphrase != settingsKey.passphrase || salt == nil {
+		salt = make([]byte, 32)
+		if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+			settingsKey.mx.Unlock()
+			return err
+		}
+	}
+	settingsKey.mx.Unlock()
+
+	if key, err = targetSettingsKey(passphrase, salt); err != nil {
+		return err
+	}
+	if aead, err = newSettingsAEAD(key); err != nil {
+		return err
+	}
+	file := &encryptedSettings{
+		Version: targetSettingsVersion,
+		Salt:    salt,
+		Nonce:   make([]byte, aead.NonceSize()),
+	}
+	if _, err = io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = aead.Seal(nil, file.Nonce, data, nil)
+	if data, err = json.Marshal(file); err != nil {
+		return err
+	}
+
+	path := targetSettingsFile()
+	if tmpFile, err = os.CreateTemp(filepath.Dir(path), "targets.dat.*"); err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), path); err != nil {
+		return err
+	}
+	// commands such as 'cb space connect' re-spawn the CLI
+	// with elevated privileges so ensure the file remains
+	// owned by the user that owns the configuration
+	return chownToParent(path)
+}
+
+// returns the key derived from the passphrase and salt
+func targetSettingsKey(passphrase string, salt []byte) ([]byte, error) {
+
+	var (
+		err error
+
+		key []byte
+	)
+
+	settingsKey.mx.Lock()
+	defer settingsKey.mx.Unlock()
+
+	if settingsKey.key != nil &&
+		settingsKey.passphrase == passphrase && string(settingsKey.salt) == string(salt) {
+		return settingsKey.key, nil
+	}
+	if key, err = scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32); err != nil {
+		return nil, err
+	}
+	settingsKey.passphrase = passphrase
+	settingsKey.salt = salt
+	settingsKey.key = key
+	return key, nil
+}
+
+func newSettingsAEAD(key []byte) (cipher.AEAD, error) {
+
+	var (
+		err error
+
+		block cipher.Block
+	)
+
+	if block, err = aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// acquires an exclusive lock on the settings file so that
+// commands running concurrently, such as the scheduler,
+// do not overwrite each other's changes
+func lockTargetSettings() (func(), error) {
+
+	var (
+		err error
+
+		lockFile *os.File
+		info     os.FileInfo
+	)
+
+	lockPath := targetSettingsFile() + ".lock"
+	timeout := time.Now().Add(30 * time.Second)
+	for {
+		if lockFile, err = os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600); err == nil {
+			fmt.Fprintf(lockFile, "%d\n", os.Getpid())
+			lockFile.Close()
+			return func() {
+				os.Remove(lockPath)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		// remove locks left behind by
+		// processes that were killed
+		if info, err = os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(timeout) {
+			return nil, fmt.Errorf("timed out waiting for the target settings lock '%s'", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,375 @@
+package cookbook
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/gobuffalo/packr/v2"
+	homedir "github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+// hours used to convert hourly prices to monthly costs
+const hoursPerMonth = 730
+
+// name of the pricing catalogue imported to the
+// local workspace to update the bundled catalogue
+const pricingCatalogueFile = "pricing.yml"
+
+// Pricing catalogue of the resources that recipes
+// deploy. Prices are listed by cloud and region.
+type PricingCatalogue struct {
+	// date the prices were last updated (YYYY-MM-DD)
+	Updated  string `yaml:"updated"`
+	Currency string `yaml:"currency"`
+
+	Clouds map[string]map[string]*RegionPricing `yaml:"clouds"`
+}
+
+type RegionPricing struct {
+	// hourly price of each instance type
+	Instances map[string]float64 `yaml:"instances"`
+	// monthly price of a GB of disk storage
+	DiskGBMonth float64 `yaml:"diskGBMonth"`
+	// hourly price of a public IP
+	PublicIPHour float64 `yaml:"publicIPHour"`
+}
+
+// A resource in a launch plan along with its
+// attributes before and after the plan is applied
+type PlannedResource struct {
+	Address string
+	Type    string
+	Action  string
+
+	Before map[string]string
+	After  map[string]string
+}
+
+const (
+	PlanCreate  = "create"
+	PlanUpdate  = "update"
+	PlanReplace = "replace"
+	PlanDestroy = "destroy"
+)
+
+// value of an attribute that is only
+// known once a launch plan is applied
+const UnknownValue = "(known after apply)"
+
+type CostItem struct {
+	Address     string  `json:"address" yaml:"address"`
+	Action      string  `json:"action" yaml:"action"`
+	Description string  `json:"description" yaml:"description"`
+	Monthly     float64 `json:"monthly" yaml:"monthly"`
+	Change      float64 `json:"change" yaml:"change"`
+}
+
+type CostEstimate struct {
+	Currency string `json:"currency" yaml:"currency"`
+	// date of the prices used for the estimate
+	PricesUpdated string `json:"pricesUpdated" yaml:"pricesUpdated"`
+
+	Items []CostItem `json:"items" yaml:"items"`
+	// resources that could not be priced
+	Unpriced []string `json:"unpriced,omitempty" yaml:"unpriced,omitempty"`
+
+	// monthly cost of the resources created
+	// or changed by the plan once it is applied
+	Monthly float64 `json:"monthly" yaml:"monthly"`
+	// change in monthly cost once the plan is applied
+	Change float64 `json:"change" yaml:"change"`
+	// monthly cost of all of the resources of the
+	// deployment once the plan is applied
+	Total float64 `json:"total" yaml:"total"`
+}
+
+const (
+	instancePrice = iota
+	diskPrice
+	publicIPPrice
+)
+
+type pricedAttribute struct {
+	price     int
+	attribute string
+}
+
+// resource types that are priced and the attributes
+// of the resource their price is determined from
+var pricedResources = map[string][]pricedAttribute{
+	"aws_instance": {
+		{instancePrice, "instance_type"},
+		{diskPrice, "root_block_device.volume_size"},
+	},
+	"aws_ebs_volume": {
+		{diskPrice, "size"},
+	},
+	"aws_eip": {
+		{publicIPPrice, ""},
+	},
+	"google_compute_instance": {
+		{instancePrice, "machine_type"},
+		{diskPrice, "boot_disk.initialize_params.size"},
+	},
+	"google_compute_disk": {
+		{diskPrice, "size"},
+	},
+	"google_compute_address": {
+		{publicIPPrice, ""},
+	},
+	"azurerm_linux_virtual_machine": {
+		{instancePrice, "size"},
+		{diskPrice, "os_disk.disk_size_gb"},
+	},
+	"azurerm_windows_virtual_machine": {
+		{instancePrice, "size"},
+		{diskPrice, "os_disk.disk_size_gb"},
+	},
+	"azurerm_virtual_machine": {
+		{instancePrice, "vm_size"},
+	},
+	"azurerm_managed_disk": {
+		{diskPrice, "disk_size_gb"},
+	},
+	"azurerm_public_ip": {
+		{publicIPPrice, ""},
+	},
+}
+
+// Loads the pricing catalogue bundled with the
+// CLI or the catalogue imported to the local
+// workspace if it is more recent.
+func LoadPricingCatalogue() (*PricingCatalogue, error) {
+
+	var (
+		err error
+
+		data     []byte
+		path     string
+		bundled  *PricingCatalogue
+		imported *PricingCatalogue
+	)
+
+	box := packr.New("pricing", "./pricing")
+	if data, err = box.Find("catalogue.yml"); err != nil {
+		return nil, err
+	}
+	if bundled, err = ParsePricingCatalogue(data); err != nil {
+		return nil, fmt.Errorf("invalid bundled pricing catalogue: %s", err.Error())
+	}
+
+	if path, err = pricingCataloguePath(); err != nil {
+		return nil, err
+	}
+	if data, err = os.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			return bundled, nil
+		}
+		return nil, err
+	}
+	if imported, err = ParsePricingCatalogue(data); err != nil {
+		return nil, fmt.Errorf("invalid pricing catalogue at '%s': %s", path, err.Error())
+	}
+	if imported.Updated < bundled.Updated {
+		return bundled, nil
+	}
+	return imported, nil
+}
+
+// Imports the pricing catalogue at the given path
+// to the local workspace. This allows prices to be
+// updated without access to any external service.
+func ImportPricingCatalogue(srcPath string) (*PricingCatalogue, error) {
+
+	var (
+		err error
+
+		data      []byte
+		path      string
+		catalogue *PricingCatalogue
+	)
+
+	if data, err = os.ReadFile(srcPath); err != nil {
+		return nil, err
+	}
+	if catalogue, err = ParsePricingCatalogue(data); err != nil {
+		return nil, err
+	}
+	if path, err = pricingCataloguePath(); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+	return catalogue, nil
+}
+
+// Parses and validates a pricing catalogue.
+func ParsePricingCatalogue(data []byte) (*PricingCatalogue, error) {
+
+	var (
+		err error
+	)
+
+	catalogue := &PricingCatalogue{}
+	if err = yaml.Unmarshal(data, catalogue); err != nil {
+		return nil, err
+	}
+	if len(catalogue.Updated) == 0 {
+		return nil, fmt.Errorf("the catalogue does not have an 'updated' date")
+	}
+	if len(catalogue.Currency) == 0 {
+		return nil, fmt.Errorf("the catalogue does not have a 'currency'")
+	}
+	if len(catalogue.Clouds) == 0 {
+		return nil, fmt.Errorf("the catalogue does not have any cloud prices")
+	}
+	return catalogue, nil
+}
+
+// Estimates the monthly cost of the given planned
+// resources when deployed to the given cloud region.
+// The deployed resources are the resources of the
+// deployment before the plan is applied. Their
+// attributes are given as the values after they were
+// last applied. Those the plan does not change are
+// included in the total cost of the deployment.
+func (c *PricingCatalogue) Estimate(cloud, region string, deployed, resources []*PlannedResource) *CostEstimate {
+
+	estimate := &CostEstimate{
+		Currency:      c.Currency,
+		PricesUpdated: c.Updated,
+		Items:         []CostItem{},
+	}
+
+	var pricing *RegionPricing
+	if regions, ok := c.Clouds[cloud]; ok {
+		pricing = regions[region]
+	}
+
+	planned := make(map[string]bool)
+	for _, resource := range resources {
+		planned[resource.Address] = true
+
+		attributes, ok := pricedResources[resource.Type]
+		if !ok {
+			continue
+		}
+		if pricing == nil {
+			estimate.Unpriced = append(estimate.Unpriced,
+				fmt.Sprintf("%s (no prices for %s region %s)", resource.Address, cloud, region))
+			continue
+		}
+
+		for _, attribute := range attributes {
+			before, previous, _ := pricing.price(attribute, resource.Before)
+			after, description, err := pricing.price(attribute, resource.After)
+			if err != nil {
+				estimate.Unpriced = append(estimate.Unpriced,
+					fmt.Sprintf("%s (%s)", resource.Address, err.Error()))
+				continue
+			}
+			if len(description) == 0 {
+				// resource is being destroyed
+				if description = previous; len(description) == 0 {
+					continue
+				}
+			}
+
+			item := CostItem{
+				Address:     resource.Address,
+				Action:      resource.Action,
+				Description: description,
+				Monthly:     after,
+				Change:      after - before,
+			}
+			estimate.Items = append(estimate.Items, item)
+			estimate.Monthly += item.Monthly
+			estimate.Change += item.Change
+		}
+	}
+
+	estimate.Total = estimate.Monthly
+	for _, resource := range deployed {
+		attributes, ok := pricedResources[resource.Type]
+		if !ok || planned[resource.Address] {
+			continue
+		}
+		if pricing == nil {
+			estimate.Unpriced = append(estimate.Unpriced,
+				fmt.Sprintf("%s (no prices for %s region %s)", resource.Address, cloud, region))
+			continue
+		}
+		for _, attribute := range attributes {
+			monthly, _, err := pricing.price(attribute, resource.After)
+			if err != nil {
+				estimate.Unpriced = append(estimate.Unpriced,
+					fmt.Sprintf("%s (%s)", resource.Address, err.Error()))
+				continue
+			}
+			estimate.Total += monthly
+		}
+	}
+
+	sort.SliceStable(estimate.Items, func(i, j int) bool {
+		return estimate.Items[i].Address < estimate.Items[j].Address
+	})
+	return estimate
+}
+
+// returns the monthly price and a description of the
+// priced attribute of a resource with the given
+// attribute values. if the attribute has no value
+// then a zero price and empty description is
+// returned as there is nothing to price.
+func (p *RegionPricing) price(attribute pricedAttribute, values map[string]string) (float64, string, error) {
+
+	if values == nil {
+		return 0, "", nil
+	}
+	value, ok := values[attribute.attribute]
+	if len(attribute.attribute) > 0 && (!ok || len(value) == 0) {
+		return 0, "", nil
+	}
+	if value == UnknownValue {
+		return 0, "", fmt.Errorf("%s is not known until the plan is applied", attribute.attribute)
+	}
+
+	switch attribute.price {
+	case instancePrice:
+		hourly, ok := p.Instances[value]
+		if !ok {
+			return 0, "", fmt.Errorf("no price for instance type %s", value)
+		}
+		return hourly * hoursPerMonth, value, nil
+
+	case diskPrice:
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid disk size %s", value)
+		}
+		return size * p.DiskGBMonth, fmt.Sprintf("%s GB disk", value), nil
+
+	case publicIPPrice:
+		return p.PublicIPHour * hoursPerMonth, "public IP", nil
+	}
+	return 0, "", nil
+}
+
+func pricingCataloguePath() (string, error) {
+
+	var (
+		err error
+
+		homeDir string
+	)
+
+	if homeDir, err = homedir.Dir(); err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cb", pricingCatalogueFile), nil
+}
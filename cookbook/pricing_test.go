@@ -0,0 +1,193 @@
+package cookbook
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func testCatalogue() *PricingCatalogue {
+	return &PricingCatalogue{
+		Updated:  "2024-01-01",
+		Currency: "USD",
+		Clouds: map[string]map[string]*RegionPricing{
+			"aws": {
+				"us-east-1": {
+					Instances: map[string]float64{
+						"t3.micro":  0.01,
+						"t3.medium": 0.04,
+					},
+					DiskGBMonth:  0.1,
+					PublicIPHour: 0.005,
+				},
+			},
+		},
+	}
+}
+
+func assertCost(t *testing.T, name string, expected, actual float64) {
+	if math.Abs(expected-actual) > 0.001 {
+		t.Fatalf("expected %s to be %.3f but got %.3f", name, expected, actual)
+	}
+}
+
+func TestEstimateCreate(t *testing.T) {
+
+	estimate := testCatalogue().Estimate("aws", "us-east-1", nil, []*PlannedResource{
+		{
+			Address: "aws_instance.bastion",
+			Type:    "aws_instance",
+			Action:  PlanCreate,
+			After: map[string]string{
+				"instance_type":                 "t3.micro",
+				"root_block_device.volume_size": "20",
+			},
+		},
+		{
+			Address: "aws_eip.bastion",
+			Type:    "aws_eip",
+			Action:  PlanCreate,
+			After:   map[string]string{},
+		},
+		{
+			Address: "aws_vpc.main",
+			Type:    "aws_vpc",
+			Action:  PlanCreate,
+			After:   map[string]string{},
+		},
+	})
+
+	if len(estimate.Unpriced) != 0 {
+		t.Fatalf("expected all resources to be priced but got unpriced %v", estimate.Unpriced)
+	}
+	if len(estimate.Items) != 3 {
+		t.Fatalf("expected 3 cost items but got %d", len(estimate.Items))
+	}
+	// 7.30 instance + 2.00 disk + 3.65 public ip
+	assertCost(t, "monthly", 12.95, estimate.Monthly)
+	assertCost(t, "change", 12.95, estimate.Change)
+	assertCost(t, "total", 12.95, estimate.Total)
+}
+
+func TestEstimateUpdateAndDestroy(t *testing.T) {
+
+	estimate := testCatalogue().Estimate("aws", "us-east-1", nil, []*PlannedResource{
+		{
+			Address: "aws_instance.app",
+			Type:    "aws_instance",
+			Action:  PlanUpdate,
+			Before:  map[string]string{"instance_type": "t3.micro"},
+			After:   map[string]string{"instance_type": "t3.medium"},
+		},
+		{
+			Address: "aws_eip.app",
+			Type:    "aws_eip",
+			Action:  PlanDestroy,
+			Before:  map[string]string{},
+		},
+	})
+
+	if len(estimate.Unpriced) != 0 {
+		t.Fatalf("expected all resources to be priced but got unpriced %v", estimate.Unpriced)
+	}
+	// 29.20 for the updated instance
+	assertCost(t, "monthly", 29.2, estimate.Monthly)
+	// +21.90 for the instance and -3.65 for the public ip
+	assertCost(t, "change", 18.25, estimate.Change)
+	assertCost(t, "total", 29.2, estimate.Total)
+}
+
+func TestEstimateUnpriced(t *testing.T) {
+
+	catalogue := testCatalogue()
+	resources := []*PlannedResource{
+		{
+			Address: "aws_instance.unknown_type",
+			Type:    "aws_instance",
+			Action:  PlanCreate,
+			After:   map[string]string{"instance_type": "m5.large"},
+		},
+		{
+			Address: "aws_ebs_volume.unknown_size",
+			Type:    "aws_ebs_volume",
+			Action:  PlanCreate,
+			After:   map[string]string{"size": UnknownValue},
+		},
+	}
+
+	estimate := catalogue.Estimate("aws", "us-east-1", nil, resources)
+	if len(estimate.Unpriced) != 2 {
+		t.Fatalf("expected 2 unpriced resources but got %v", estimate.Unpriced)
+	}
+	if !strings.HasPrefix(estimate.Unpriced[0], "aws_instance.unknown_type") ||
+		!strings.HasPrefix(estimate.Unpriced[1], "aws_ebs_volume.unknown_size") {
+		t.Fatalf("unexpected unpriced resources %v", estimate.Unpriced)
+	}
+	assertCost(t, "total", 0, estimate.Total)
+
+	// all priced resources are unpriced
+	// in a region without prices
+	estimate = catalogue.Estimate("aws", "eu-west-1", []*PlannedResource{
+		{
+			Address: "aws_eip.deployed",
+			Type:    "aws_eip",
+			After:   map[string]string{},
+		},
+	}, resources)
+	if len(estimate.Unpriced) != 3 {
+		t.Fatalf("expected 3 unpriced resources but got %v", estimate.Unpriced)
+	}
+}
+
+func TestEstimateDeployed(t *testing.T) {
+
+	deployed := []*PlannedResource{
+		{
+			Address: "aws_instance.bastion",
+			Type:    "aws_instance",
+			After: map[string]string{
+				"instance_type":                 "t3.micro",
+				"root_block_device.volume_size": "20",
+			},
+		},
+		{
+			Address: "aws_instance.app",
+			Type:    "aws_instance",
+			After:   map[string]string{"instance_type": "t3.micro"},
+		},
+		{
+			Address: "aws_vpc.main",
+			Type:    "aws_vpc",
+			After:   map[string]string{"cidr_block": "10.0.0.0/16"},
+		},
+	}
+	// the planned update of a deployed resource
+	// replaces its deployed cost in the total
+	estimate := testCatalogue().Estimate("aws", "us-east-1", deployed, []*PlannedResource{
+		{
+			Address: "aws_instance.app",
+			Type:    "aws_instance",
+			Action:  PlanUpdate,
+			Before:  map[string]string{"instance_type": "t3.micro"},
+			After:   map[string]string{"instance_type": "t3.medium"},
+		},
+	})
+
+	if len(estimate.Unpriced) != 0 {
+		t.Fatalf("expected all resources to be priced but got unpriced %v", estimate.Unpriced)
+	}
+	if len(estimate.Items) != 1 {
+		t.Fatalf("expected only the planned resource to be itemized but got %d items", len(estimate.Items))
+	}
+	assertCost(t, "monthly", 29.2, estimate.Monthly)
+	assertCost(t, "change", 21.9, estimate.Change)
+	// 29.20 for the updated instance and 9.30
+	// for the unchanged instance and its disk
+	assertCost(t, "total", 38.5, estimate.Total)
+
+	// without a plan the total is the
+	// cost of the deployed resources
+	estimate = testCatalogue().Estimate("aws", "us-east-1", deployed, []*PlannedResource{})
+	assertCost(t, "monthly", 0, estimate.Monthly)
+	assertCost(t, "total", 16.6, estimate.Total)
+}
@@ -67,6 +67,7 @@ cognitoRegion=$(echo "$identity_outputs" | jq -r 'select(.OutputKey=="Region") |
 userPoolId=$(echo "$identity_outputs" | jq -r 'select(.OutputKey=="UserPoolId") | .OutputValue')
 cliClientID=$(echo "$identity_outputs" | jq -r 'select(.OutputKey=="UserPoolCLIClientId") | .OutputValue')
 cliClientSecret=$(echo "$identity_outputs" | jq -r 'select(.OutputKey=="UserPoolCLIClientSecret") | .OutputValue')
+deviceAuthUrl=$(echo "$identity_outputs" | jq -r 'select(.OutputKey=="DeviceAuthorizationUrl") | .OutputValue')
 appsyncRegion=$(echo "$api_outputs" | jq -r 'select(.OutputKey=="Region") | .OutputValue')
 userSpaceApiUrl=$(echo "$api_outputs" | jq -r 'select(.OutputKey=="UserSpaceApiUrl") | .OutputValue')
 
@@ -85,6 +86,7 @@ const CLIENT_ID = "${cliClientID}"
 const CLIENT_SECRET = "${cliClientSecret}"
 const AUTH_URL = "https://${env_name}.auth.us-east-1.amazoncognito.com/login"
 const TOKEN_URL = "https://${env_name}.auth.us-east-1.amazoncognito.com/oauth2/token"
+const DEVICE_AUTH_URL = "${deviceAuthUrl}"
 const USER_INFO_URL = "https://${env_name}.auth.us-east-1.amazoncognito.com/oauth2/userInfo"
 
 const AWS_APPSYNC_REGION = "${appsyncRegion}"
@@ -8,9 +8,113 @@ import (
 
 	"github.com/gookit/color"
 	"github.com/peterh/liner"
+	"gopkg.in/yaml.v3"
 )
 
+// exit code returned when the CLI is running in
+// non-interactive mode and a prompt does not
+// have an answer
+const ExitCodeInputRequired = 3
+
+var (
+	// set via the global '--non-interactive' option
+	NonInteractive bool
+	// set via the global '-y|--yes' option
+	AssumeYes bool
+	// set via the global '--answers' option
+	AnswersFile string
+
+	// answers loaded from the answers file
+	// keyed by prompt ID
+	answers = map[string]string{}
+)
+
+// loads the answers file if one was provided and
+// enables non-interactive mode if either an answers
+// file was provided or all prompts should be
+// answered with 'yes'
+func InitInput() {
+
+	var (
+		err error
+
+		data   []byte
+		values map[string]interface{}
+	)
+
+	if AssumeYes || len(AnswersFile) > 0 {
+		NonInteractive = true
+	}
+	if len(AnswersFile) == 0 {
+		return
+	}
+	if data, err = os.ReadFile(AnswersFile); err != nil {
+		ShowErrorAndExit(
+			fmt.Sprintf("Unable to read answers file '%s': %s", AnswersFile, err.Error()),
+		)
+	}
+	if err = yaml.Unmarshal(data, &values); err != nil {
+		ShowErrorAndExit(
+			fmt.Sprintf("Unable to parse answers file '%s': %s", AnswersFile, err.Error()),
+		)
+	}
+	for id, value := range values {
+		switch v := value.(type) {
+		case nil:
+			answers[id] = ""
+		case bool:
+			if v {
+				answers[id] = "yes"
+			} else {
+				answers[id] = "no"
+			}
+		default:
+			answers[id] = fmt.Sprintf("%v", v)
+		}
+	}
+}
+
+// returns the answer for the prompt with the given ID
+// and whether an answer was provided
+func GetAnswer(id string) (string, bool) {
+	answer, ok := answers[id]
+	return answer, ok
+}
+
+// returns the answer for the prompt with the given ID or
+// exits with ExitCodeInputRequired if one was not provided
+func LookupAnswer(id, prompt string) string {
+	if answer, ok := answers[id]; ok {
+		return answer
+	}
+	ExitInputRequired(id, prompt)
+	return ""
+}
+
+// exits with ExitCodeInputRequired if the CLI is running
+// in non-interactive mode. this should be called before
+// any interactive input that cannot be provided via the
+// answers file such as forms and terminal sessions.
+func AssertInteractive(id, description string) {
+	if NonInteractive {
+		ExitInputRequired(id, description)
+	}
+}
+
+// shows an error indicating that input is required
+// and exits with ExitCodeInputRequired
+func ExitInputRequired(id, prompt string) {
+	ShowErrorMessage(
+		fmt.Sprintf(
+			"Input with ID '%s' is required but the CLI is running in non-interactive mode: %s",
+			id, strings.TrimRight(strings.TrimSpace(prompt), ":?"),
+		),
+	)
+	os.Exit(ExitCodeInputRequired)
+}
+
 func GetUserInput(
+	id string,
 	prompt string,
 ) string {
 
@@ -20,6 +124,10 @@ func GetUserInput(
 		response string
 	)
 
+	if NonInteractive {
+		return LookupAnswer(id, prompt)
+	}
+
 	line := liner.NewLiner()
 	line.SetCtrlCAborts(true)
 	defer func() {
@@ -40,7 +148,31 @@ func GetUserInput(
 	return response
 }
 
+// prompts for a confirmation value such as the name of a
+// resource to delete and returns true if the response
+// matches the expected value. in non-interactive mode
+// the answer is read from the answers file or assumed
+// to be confirmed if the '--yes' option was provided.
+func GetConfirmationInput(
+	id string,
+	prompt string,
+	expected string,
+) bool {
+
+	if NonInteractive {
+		if answer, ok := answers[id]; ok {
+			return answer == expected
+		}
+		if AssumeYes {
+			return true
+		}
+		ExitInputRequired(id, prompt)
+	}
+	return GetUserInput(id, prompt) == expected
+}
+
 func GetUserInputFromList(
+	id string,
 	prompt string,
 	selected string,
 	options []string,
@@ -53,24 +185,29 @@ func GetUserInputFromList(
 		response string
 	)
 
-	line := liner.NewLiner()
-	line.SetCtrlCAborts(true)
-	line.SetCompleter(func(line string) []string {
-		return options
-	})
-	defer func() {
-		line.Close()
-	}()
+	if NonInteractive {
+		response = LookupAnswer(id, prompt)
 
-	if response, err = line.PromptWithSuggestion(
-		prompt, selected, -1,
-	); err != nil {
+	} else {
+		line := liner.NewLiner()
+		line.SetCtrlCAborts(true)
+		line.SetCompleter(func(line string) []string {
+			return options
+		})
+		defer func() {
+			line.Close()
+		}()
 
-		if err == liner.ErrPromptAborted {
-			fmt.Println(color.Red.Render("\nInput aborted.\n"))
-			os.Exit(1)
-		} else {
-			ShowErrorAndExit(err.Error())
+		if response, err = line.PromptWithSuggestion(
+			prompt, selected, -1,
+		); err != nil {
+
+			if err == liner.ErrPromptAborted {
+				fmt.Println(color.Red.Render("\nInput aborted.\n"))
+				os.Exit(1)
+			} else {
+				ShowErrorAndExit(err.Error())
+			}
 		}
 	}
 	if validate {
@@ -98,7 +235,7 @@ func GetUserInputFromList(
 	return response
 }
 
-func GetYesNoUserInput(prompt string, defaultRespone bool) bool {
+func GetYesNoUserInput(id string, prompt string, defaultRespone bool) bool {
 
 	var(
 		err error
@@ -106,23 +243,76 @@ func GetYesNoUserInput(prompt string, defaultRespone bool) bool {
 		defaultInput,
 		input string
 	)
-	
+
+	if NonInteractive {
+		var ok bool
+		if input, ok = answers[id]; !ok {
+			if AssumeYes {
+				return true
+			}
+			ExitInputRequired(id, prompt)
+		}
+
+	} else {
+		line := liner.NewLiner()
+		line.SetCtrlCAborts(true)
+		line.SetCompleter(func(line string) []string {
+			return []string{"yes", "no"}
+		})
+		defer func() {
+			line.Close()
+		}()
+
+		if defaultRespone {
+			defaultInput = "yes"
+		} else {
+			defaultInput = "no"
+		}
+
+		if input, err = line.PromptWithSuggestion(prompt, defaultInput, -1); err != nil {
+
+			if err == liner.ErrPromptAborted {
+				fmt.Println(color.Red.Render("\nInput aborted.\n"))
+				os.Exit(1)
+			} else {
+				ShowErrorAndExit(err.Error())
+			}
+		}
+		line.SetCompleter(nil)
+	}
+
+	input = strings.ToLower(input)
+	if match, err := regexp.Match(`^((y(es)?)|(no?))$`, []byte(input)); !match || err != nil {
+		fmt.Println(color.Red.Render("\nInvalid input.\n"))
+		os.Exit(1)
+	}
+	return input == "yes" || input == "y"
+}
+
+func GetPasswordInput(
+	id string,
+	prompt string,
+) string {
+
+	var (
+		err error
+
+		response string
+	)
+
+	if NonInteractive {
+		return LookupAnswer(id, prompt)
+	}
+
 	line := liner.NewLiner()
 	line.SetCtrlCAborts(true)
-	line.SetCompleter(func(line string) []string {
-		return []string{"yes", "no"}
-	})
 	defer func() {
 		line.Close()
 	}()
 
-	if defaultRespone {
-		defaultInput = "yes"
-	} else {
-		defaultInput = "no"
-	}
-
-	if input, err = line.PromptWithSuggestion(prompt, defaultInput, -1); err != nil {
+	if response, err = line.PasswordPrompt(
+		prompt,
+	); err != nil {
 
 		if err == liner.ErrPromptAborted {
 			fmt.Println(color.Red.Render("\nInput aborted.\n"))
@@ -131,12 +321,5 @@ func GetYesNoUserInput(prompt string, defaultRespone bool) bool {
 			ShowErrorAndExit(err.Error())
 		}
 	}
-	line.SetCompleter(nil)
-
-	input = strings.ToLower(input)
-	if match, err := regexp.Match(`^((y(es)?)|(no?))$`, []byte(input)); !match || err != nil {
-		fmt.Println(color.Red.Render("\nInvalid input.\n"))
-		os.Exit(1)
-	}
-	return input == "yes" || input == "y"
+	return response
 }
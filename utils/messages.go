@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
 	"strings"
@@ -11,6 +12,13 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// exit code the CLI exits with once a command has
+// completed and the configuration has been saved. a
+// command sets this to report its result via the exit
+// code instead of exiting before the configuration
+// is saved.
+var ExitCode int
+
 func ShowErrorAndExit(message string) {
 	
 	var (
@@ -84,6 +92,21 @@ func ShowNoteMessage(message string, args ...interface{}) {
 	)
 }
 
+// writes a note to the given writer, such as the
+// writer of a target whose output is prefixed
+func WriteNoteMessage(out io.Writer, message string, args ...interface{}) {
+	fmt.Fprintln(
+		out,
+		color.Note.Render(
+			utils.FormatMessage(
+				0, 80, false, false, 
+				message, 
+				args...,
+			),
+		),
+	)
+}
+
 func ShowNoticeMessage(message string, args ...interface{}) {
 	fmt.Println(
 		color.Notice.Render(
@@ -49,6 +49,7 @@ func (os OptionSelector) SelectOption(data interface{}, listFilter string, roleF
 		allowedOptions[o] = true
 	}
 	if response = GetUserInputFromList(
+		"select-action",
 		"Enter # of option or (q)uit: ",
 		"", optionList, false); response == "q" {
 		fmt.Println()
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gookit/color"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// output format selected via the global
+// '-o|--output' option of the CLI
+var OutputFormat = OutputTable
+
+// validates the selected output format and disables
+// color rendering if the output is not going to a
+// terminal or is to be parsed by another program
+func InitOutput() {
+
+	switch OutputFormat {
+	case OutputTable, OutputJSON, OutputYAML:
+	default:
+		ShowErrorAndExit(
+			fmt.Sprintf(
+				"Invalid output format '%s'. Output format must be one of 'table', 'json' or 'yaml'.",
+				OutputFormat,
+			),
+		)
+	}
+
+	if OutputFormat != OutputTable || !term.IsTerminal(int(os.Stdout.Fd())) {
+		color.Disable()
+	}
+}
+
+// returns true if command output should be
+// rendered as human readable tables
+func IsTableOutput() bool {
+	return OutputFormat == OutputTable
+}
+
+// writes the given data to stdout using the
+// selected machine readable output format
+func RenderOutput(data interface{}) {
+
+	var (
+		err error
+
+		out []byte
+	)
+
+	switch OutputFormat {
+	case OutputJSON:
+		if out, err = json.MarshalIndent(data, "", "  "); err == nil {
+			out = append(out, '\n')
+		}
+	case OutputYAML:
+		out, err = yaml.Marshal(data)
+	default:
+		err = fmt.Errorf("output format '%s' cannot be rendered as structured data", OutputFormat)
+	}
+	if err != nil {
+		ShowErrorAndExit(err.Error())
+	}
+	if _, err = os.Stdout.Write(out); err != nil {
+		ShowErrorAndExit(err.Error())
+	}
+}